@@ -26,17 +26,18 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"go-calculator/pkg/calculator"
 	"os"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 func readExpression() (string, error) {
-	fmt.Println(os.Args)
-	if len(os.Args) > 1 {
-		return strings.Join(os.Args[1:], " "), nil
+	if flag.NArg() > 0 {
+		return strings.Join(flag.Args(), " "), nil
 	}
 
 	fmt.Print("Enter an expression: ")
@@ -52,7 +53,20 @@ func readExpression() (string, error) {
 	return inputString, nil
 }
 
+func formatResult(res float64, separator string) string {
+	if separator != "" {
+		sep, _ := utf8.DecodeRuneInString(separator)
+		return calculator.FormatGrouped(res, sep)
+	}
+	return strconv.FormatFloat(res, 'f', -1, 64)
+}
+
 func main() {
+	echo := flag.Bool("echo", false, "print the expression alongside the result, as 'expr = result'")
+	sig := flag.Int("sig", 0, "round the result to `N` significant digits")
+	group := flag.String("group", "", "group the digits of the result by thousands with the `separator`, e.g. ','")
+	flag.Parse()
+
 	inputString, err := readExpression()
 	if err != nil {
 		fmt.Printf("Error reading expression: %s\n", err)
@@ -67,6 +81,17 @@ func main() {
 		os.Exit(1)
 		return
 	}
-	formatted := strconv.FormatFloat(res, 'f', -1, 64)
-	fmt.Printf("%s\n", formatted)
+	if *sig > 0 {
+		res = calculator.RoundSignificant(res, *sig)
+	}
+	fmt.Println(formatOutput(inputString, formatResult(res, *group), *echo))
+}
+
+// formatOutput returns the line printed for a result, expr = result if
+// echo is set
+func formatOutput(expression string, formatted string, echo bool) string {
+	if echo {
+		return expression + " = " + formatted
+	}
+	return formatted
 }
@@ -0,0 +1,43 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+// Result is the outcome of one expression of a batch, Err is set if it
+// could not be evaluated
+type Result struct {
+	Value float64
+	Err   error
+}
+
+// EvaluateBatch evaluates each expression like EvaluateExpression and
+// returns their results in the same order. An invalid expression does not
+// stop the others from being evaluated, its error is in its result.
+func (e *Evaluator) EvaluateBatch(expressions []string) []Result {
+	results := make([]Result, len(expressions))
+	for i, expression := range expressions {
+		results[i].Value, results[i].Err = e.EvaluateExpression(expression)
+	}
+	return results
+}
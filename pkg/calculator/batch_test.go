@@ -0,0 +1,58 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import "testing"
+
+func TestEvaluateBatch(t *testing.T) {
+	expressions := []string{"1 + 2", "1 +", "sqrt(16)", "x * 2", "1 / 0", "2 ^ 10"}
+	want := []struct {
+		value float64
+		err   bool
+	}{
+		{3, false},
+		{0, true},
+		{4, false},
+		{0, true},
+		{0, true},
+		{1024, false},
+	}
+	results := NewEvaluator(Options{}).EvaluateBatch(expressions)
+	if len(results) != len(expressions) {
+		t.Fatalf("got %d results, want %d", len(results), len(expressions))
+	}
+	for i, result := range results {
+		if (result.Err != nil) != want[i].err {
+			t.Errorf("%s error = %v, want error %v", expressions[i], result.Err, want[i].err)
+		}
+		if result.Value != want[i].value {
+			t.Errorf("%s got %v, want %v", expressions[i], result.Value, want[i].value)
+		}
+	}
+
+	if results := NewEvaluator(Options{}).EvaluateBatch(nil); len(results) != 0 {
+		t.Errorf("empty batch got %v", results)
+	}
+}
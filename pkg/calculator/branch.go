@@ -0,0 +1,101 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// maxPlusMinus limits the ± operators of EvaluateAll, each one doubling
+// the number of results
+const maxPlusMinus = 16
+
+// EvaluateAll evaluates every branch of an expression with ± operators,
+// e.g. ±sqrt(9) gives [3, -3] and 1 ± 2 ± 3 gives [6, 0, 2, -4]. The
+// branches are ordered as if counting with + before -, the leftmost ±
+// changing the slowest. EvaluateExpression only gives the first one.
+func (e *Evaluator) EvaluateAll(expression string) ([]float64, error) {
+	e.setWarnings(nil)
+	tokens, err := e.tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	polishNotation, err := e.toReversePolishNotation(tokens)
+	if err != nil {
+		return nil, err
+	}
+	operators := e.resolve(polishNotation)
+
+	var branches []int
+	for i, operatorEvaluator := range operators {
+		switch operatorEvaluator.(type) {
+		case plusMinusEvaluator, prefixPlusMinusEvaluator:
+			branches = append(branches, i)
+		}
+	}
+	if len(branches) > maxPlusMinus {
+		return nil, fmt.Errorf("too many ± operators, at most %d are allowed", maxPlusMinus)
+	}
+	// in the order written rather than evaluated
+	slices.SortFunc(branches, func(a, b int) int {
+		return polishNotation[a].Start - polishNotation[b].Start
+	})
+
+	ev := &evaluation{ctx: context.Background()}
+	defer func() { e.setWarnings(ev.warnings) }()
+	results := make([]float64, 0, 1<<len(branches))
+	for mask := 0; mask < 1<<len(branches); mask++ {
+		for i, index := range branches {
+			minus := mask>>(len(branches)-1-i)&1 == 1
+			operators[index] = plusMinusBranch(operators[index], minus)
+		}
+		result, err := e.run(ev, polishNotation, operators, nil)
+		if err != nil {
+			return nil, err
+		}
+		result, err = e.checkFinal(result)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// plusMinusBranch returns the operator evaluating one branch of ±
+func plusMinusBranch(operatorEvaluator OperatorEvaluator, minus bool) OperatorEvaluator {
+	if operatorEvaluator.Type() == Prefix {
+		if minus {
+			return negationEvaluator{}
+		}
+		return prefixPlusMinusEvaluator{}
+	}
+	if minus {
+		return subtractionEvaluator{}
+	}
+	return plusMinusEvaluator{}
+}
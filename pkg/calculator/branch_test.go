@@ -0,0 +1,76 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEvaluateAll(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       []float64
+	}{
+		{"±sqrt(9)", []float64{3, -3}},
+		{"1 ± 2", []float64{3, -1}},
+		// the cartesian product of the branches, the leftmost ± slowest
+		{"1 ± 2 ± 3", []float64{6, 0, 2, -4}},
+		{"(1 ± 1) * (2 ± 1)", []float64{6, 2, 0, 0}},
+		{"±1 ± 2", []float64{3, -1, 1, -3}},
+		{"5", []float64{5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateAll(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateAllFirstBranch(t *testing.T) {
+	got, err := NewEvaluator(Options{}).EvaluateExpression("±sqrt(9) ± 1")
+	if err != nil || got != 4 {
+		t.Errorf("got %v, %v, want the first branch 4", got, err)
+	}
+}
+
+func TestEvaluateAllTooMany(t *testing.T) {
+	expression := "1" + strings.Repeat(" ± 1", maxPlusMinus+1)
+	if _, err := NewEvaluator(Options{}).EvaluateAll(expression); err == nil {
+		t.Errorf("evaluated %d ± operators", maxPlusMinus+1)
+	}
+	expression = "1" + strings.Repeat(" ± 1", maxPlusMinus)
+	got, err := NewEvaluator(Options{}).EvaluateAll(expression)
+	if err != nil || len(got) != 1<<maxPlusMinus {
+		t.Errorf("got %d results, %v, want %d", len(got), err, 1<<maxPlusMinus)
+	}
+}
@@ -0,0 +1,206 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"container/list"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// resultCache keeps the results of the latest evaluations of a compiled
+// expression, evicting the least recently used one when full. It is safe
+// for concurrent use.
+type resultCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	// order has the most recently used entry at the front
+	order *list.List
+}
+
+type cacheEntry struct {
+	key      string
+	result   float64
+	warnings []string
+}
+
+func newResultCache(size int) *resultCache {
+	return &resultCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *resultCache) get(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*cacheEntry), true
+}
+
+func (c *resultCache) put(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[entry.key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *resultCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// EnableCache makes the compiled expression remember the results of the
+// last size distinct variable values it was evaluated with, so evaluating
+// the same point again returns at once. Errors are not cached. A size of
+// zero or less disables the cache.
+//
+// The values of the constants used, also by the user defined functions
+// called, are part of the key, so redefining one with RegisterConstant does
+// not return stale results. An expression
+// calling random() or now(), also through a user defined function, is
+// never cached.
+//
+// Changing the options of the Evaluator does not invalidate cached results,
+// call EnableCache again to clear them. The cache may be used by concurrent
+// evaluations, but EnableCache must not be called during one.
+func (c *CompiledExpression) EnableCache(size int) {
+	if size <= 0 || slices.ContainsFunc(c.operators, isVolatile) {
+		c.cache = nil
+		return
+	}
+	c.cache = newResultCache(size)
+	c.variables = nil
+	for _, t := range c.polishNotation {
+		if t.Type == Variable && !slices.Contains(c.variables, t.Value) {
+			c.variables = append(c.variables, t.Value)
+		}
+	}
+	c.constants = functionConstants(c.operators)
+}
+
+// functionConstants returns the names the user defined functions among the
+// operators, and those they call, read as constants rather than parameters
+func functionConstants(operators []OperatorEvaluator) []string {
+	var names []string
+	seen := map[*userFunction]bool{}
+	var visit func(operators []OperatorEvaluator)
+	visit = func(operators []OperatorEvaluator) {
+		for _, operatorEvaluator := range operators {
+			function, ok := operatorEvaluator.(*userFunction)
+			// a function calling itself is already being visited
+			if !ok || seen[function] {
+				continue
+			}
+			seen[function] = true
+			for _, t := range function.body {
+				if t.Type == Variable && !slices.Contains(function.params, t.Value) &&
+					!slices.Contains(names, t.Value) {
+					names = append(names, t.Value)
+				}
+			}
+			visit(function.operators)
+		}
+	}
+	visit(operators)
+	return names
+}
+
+// CacheLen returns the number of cached results
+func (c *CompiledExpression) CacheLen() int {
+	if c.cache == nil {
+		return 0
+	}
+	return c.cache.len()
+}
+
+// cacheKey encodes the values of the variables used by the expression,
+// falling back to constants as evaluating does, then those of the constants
+// of the functions called, telling a missing one apart from any value
+func (c *CompiledExpression) cacheKey(vars map[string]float64) string {
+	key := strings.Builder{}
+	writeValue := func(value float64, ok bool) {
+		if !ok {
+			key.WriteString("-,")
+			return
+		}
+		key.WriteString(strconv.FormatUint(math.Float64bits(value), 16))
+		key.WriteByte(',')
+	}
+	for _, name := range c.variables {
+		value, ok := vars[name]
+		if !ok {
+			value, ok = c.evaluator.constant(name)
+		}
+		writeValue(value, ok)
+	}
+	key.WriteByte('|')
+	for _, name := range c.constants {
+		writeValue(c.evaluator.constant(name))
+	}
+	return key.String()
+}
+
+// isVolatile returns true if the operator may give another result for the
+// same operands, like random(), including user defined functions calling one
+func isVolatile(operatorEvaluator OperatorEvaluator) bool {
+	return isVolatileFunction(operatorEvaluator, map[*userFunction]bool{})
+}
+
+func isVolatileFunction(operatorEvaluator OperatorEvaluator, seen map[*userFunction]bool) bool {
+	switch operatorEvaluator := operatorEvaluator.(type) {
+	case randomEvaluator, nowEvaluator:
+		return true
+	case *userFunction:
+		// a function calling itself is already being checked
+		if seen[operatorEvaluator] {
+			return false
+		}
+		seen[operatorEvaluator] = true
+		return slices.ContainsFunc(operatorEvaluator.operators, func(o OperatorEvaluator) bool {
+			return isVolatileFunction(o, seen)
+		})
+	}
+	return false
+}
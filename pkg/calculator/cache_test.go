@@ -0,0 +1,160 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestEnableCache(t *testing.T) {
+	e := NewEvaluator(Options{})
+	c, err := e.Compile("x * k + 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.EnableCache(2)
+
+	evaluate := func(vars map[string]float64, want float64) {
+		t.Helper()
+		got, err := c.Evaluate(vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Evaluate(%v) = %v, want %v", vars, got, want)
+		}
+	}
+	evaluate(map[string]float64{"x": 2, "k": 3}, 7)
+	evaluate(map[string]float64{"x": 2, "k": 3}, 7)
+	if n := c.CacheLen(); n != 1 {
+		t.Errorf("CacheLen() = %d, want 1", n)
+	}
+	evaluate(map[string]float64{"x": 3, "k": 3}, 10)
+	evaluate(map[string]float64{"x": 4, "k": 3}, 13)
+	if n := c.CacheLen(); n != 2 {
+		t.Errorf("CacheLen() = %d after eviction, want 2", n)
+	}
+
+	// k falls back to the constant, keyed by its current value
+	if err := e.RegisterConstant("k", 10); err != nil {
+		t.Fatal(err)
+	}
+	evaluate(map[string]float64{"x": 2}, 21)
+	if err := e.RegisterConstant("k", 100); err != nil {
+		t.Fatal(err)
+	}
+	evaluate(map[string]float64{"x": 2}, 201)
+
+	c.EnableCache(0)
+	if n := c.CacheLen(); n != 0 {
+		t.Errorf("CacheLen() = %d when disabled, want 0", n)
+	}
+}
+
+func TestEnableCacheFunctionConstants(t *testing.T) {
+	e := NewEvaluator(Options{})
+	if err := e.RegisterConstant("rate", 2); err != nil {
+		t.Fatal(err)
+	}
+	// rate is read by a function called by the function the expression calls
+	if _, err := e.EvaluateScript("scale(x) = x * rate; total(x) = scale(x) + 1; 0"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := e.Compile("total(x)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.EnableCache(4)
+
+	evaluate := func(want float64) {
+		t.Helper()
+		got, err := c.Evaluate(map[string]float64{"x": 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+	evaluate(7)
+	evaluate(7)
+	if n := c.CacheLen(); n != 1 {
+		t.Errorf("CacheLen() = %d, want 1", n)
+	}
+	if err := e.RegisterConstant("rate", 10); err != nil {
+		t.Fatal(err)
+	}
+	evaluate(31)
+	if n := c.CacheLen(); n != 2 {
+		t.Errorf("CacheLen() = %d after changing the constant, want 2", n)
+	}
+}
+
+func TestEnableCacheVolatile(t *testing.T) {
+	e := NewEvaluator(Options{})
+	if _, err := e.EvaluateScript("noise(x) = x + random(); twice(x) = noise(x) * 2; 0"); err != nil {
+		t.Fatal(err)
+	}
+	for _, expression := range []string{"random()", "now() + x", "twice(x)"} {
+		c, err := e.Compile(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.EnableCache(4)
+		for i := 0; i < 3; i++ {
+			if _, err := c.Evaluate(map[string]float64{"x": 1}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if n := c.CacheLen(); n != 0 {
+			t.Errorf("%s: CacheLen() = %d, want 0", expression, n)
+		}
+	}
+}
+
+func TestEnableCacheConcurrent(t *testing.T) {
+	c, err := NewEvaluator(Options{}).Compile("x ^ 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.EnableCache(8)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				x := float64(j % 16)
+				got, err := c.Evaluate(map[string]float64{"x": x})
+				if err != nil || got != x*x {
+					t.Errorf("Evaluate(%v) = %v, %v, want %v", x, got, err, x*x)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
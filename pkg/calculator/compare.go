@@ -0,0 +1,68 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import "math"
+
+// ApproxEqual returns true if a and b are at most ulps representable
+// float64 values apart, which unlike a fixed epsilon scales with the
+// magnitude of the values. NaN is not equal to anything.
+func ApproxEqual(a, b float64, ulps int) bool {
+	if a == b {
+		return true
+	}
+	if math.IsNaN(a) || math.IsNaN(b) || math.IsInf(a, 0) || math.IsInf(b, 0) || ulps < 0 {
+		return false
+	}
+	ia, ib := orderedBits(a), orderedBits(b)
+	var distance uint64
+	if ia > ib {
+		distance = uint64(ia) - uint64(ib)
+	} else {
+		distance = uint64(ib) - uint64(ia)
+	}
+	return distance <= uint64(ulps)
+}
+
+// orderedBits maps a float64 to an int64 so that adjacent floats map to
+// adjacent integers, with negative floats below positive ones
+func orderedBits(f float64) int64 {
+	bits := int64(math.Float64bits(f))
+	if bits < 0 {
+		return math.MinInt64 - bits
+	}
+	return bits
+}
+
+// Check evaluates the expression and returns true if the result is at most
+// tol away from expected, e.g. for grading answers. An error is returned
+// only if the expression cannot be evaluated.
+func (e *Evaluator) Check(expression string, expected float64, tol float64) (bool, error) {
+	result, err := e.EvaluateExpression(expression)
+	if err != nil {
+		return false, err
+	}
+	return result == expected || math.Abs(result-expected) <= tol, nil
+}
@@ -0,0 +1,95 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestApproxEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b float64
+		ulps int
+		want bool
+	}{
+		{"equal", 1, 1, 0, true},
+		{"one ulp", 1, math.Nextafter(1, 2), 1, true},
+		{"one ulp strict", 1, math.Nextafter(1, 2), 0, false},
+		{"0.1 + 0.2", 0.1 + 0.2, 0.3, 1, true},
+		{"three ulps", 1e300, math.Nextafter(math.Nextafter(math.Nextafter(1e300, 2e300), 2e300), 2e300), 3, true},
+		{"three ulps of two", 1e-300, math.Nextafter(math.Nextafter(math.Nextafter(1e-300, 1), 1), 1), 2, false},
+		{"across zero", math.Copysign(0, -1), 0, 0, true},
+		{"smallest around zero", -math.SmallestNonzeroFloat64, math.SmallestNonzeroFloat64, 2, true},
+		{"far apart", 1, 1.0001, 1000, false},
+		{"nan", math.NaN(), math.NaN(), 10, false},
+		{"infinity", math.Inf(1), math.Inf(1), 0, true},
+		{"infinity and max", math.Inf(1), math.MaxFloat64, 1, false},
+		{"negative ulps", 1, math.Nextafter(1, 2), -1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApproxEqual(tt.a, tt.b, tt.ulps); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if got := ApproxEqual(tt.b, tt.a, tt.ulps); got != tt.want {
+				t.Errorf("swapped got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   float64
+		tol        float64
+		want       bool
+	}{
+		{"1 + 2", 3, 0, true},
+		{"1 / 3", 0.333, 1e-3, true},
+		{"1 / 3", 0.333, 1e-4, false},
+		{"sqrt(2)", 1.41421356, 1e-8, true},
+		{"2 ^ 10", 1000, 10, false},
+		{"-5", 5, 9.9, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).Check(tt.expression, tt.expected, tt.tol)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+	for _, expression := range []string{"1 +", "1 / 0"} {
+		if _, err := NewEvaluator(Options{}).Check(expression, 1, 1); err == nil {
+			t.Errorf("%s got no error", expression)
+		}
+	}
+}
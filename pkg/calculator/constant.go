@@ -0,0 +1,114 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// ConstantInfo is a constant with its metadata, e.g. for a UI listing the
+// constants. Only the value matters to evaluations.
+type ConstantInfo struct {
+	Name        string
+	Value       float64
+	Description string
+	// Unit of the value, empty if dimensionless
+	Unit string
+}
+
+// defaultConstants are the constants known to every Evaluator
+var defaultConstants = map[string]ConstantInfo{
+	"pi":  {Name: "pi", Value: math.Pi, Description: "ratio of a circle's circumference to its diameter"},
+	"e":   {Name: "e", Value: math.E, Description: "base of the natural logarithm"},
+	"nan": {Name: "nan", Value: math.NaN(), Description: "not a number"},
+}
+
+// RegisterConstant makes name usable as a number in expressions, e.g.
+// RegisterConstant("phi", 1.618) allows 2 * phi. The constants pi, e and nan
+// are always registered and can be redefined.
+//
+// A variable passed to the evaluation takes precedence over a constant
+// of the same name.
+func (e *Evaluator) RegisterConstant(name string, value float64) error {
+	return e.RegisterConstantInfo(ConstantInfo{Name: name, Value: value})
+}
+
+// RegisterConstantInfo registers a constant like RegisterConstant along with
+// its description and unit, e.g.
+//
+//	RegisterConstantInfo(ConstantInfo{Name: "c", Value: 299792458,
+//		Description: "speed of light in vacuum", Unit: "m/s"})
+func (e *Evaluator) RegisterConstantInfo(info ConstantInfo) error {
+	if !namePattern.MatchString(info.Name) {
+		return fmt.Errorf("invalid constant name %q", info.Name)
+	}
+	if e.isOperator(info.Name) {
+		return fmt.Errorf("constant %s conflicts with a function of the same name", info.Name)
+	}
+	if e.constants == nil {
+		e.constants = make(map[string]ConstantInfo)
+	}
+	e.constants[info.Name] = info
+	return nil
+}
+
+// Constant returns the constant of the name with its metadata
+func (e *Evaluator) Constant(name string) (ConstantInfo, bool) {
+	if info, ok := e.constants[name]; ok {
+		return info, true
+	}
+	info, ok := defaultConstants[name]
+	return info, ok
+}
+
+// Constants returns the registered and default constants sorted by name
+func (e *Evaluator) Constants() []ConstantInfo {
+	constants := maps.Clone(defaultConstants)
+	maps.Copy(constants, e.constants)
+	return slices.SortedFunc(maps.Values(constants), func(a, b ConstantInfo) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+// constant returns the value of the constant, in any case if
+// CaseInsensitiveFunctions is set, preferring the exact name
+func (e *Evaluator) constant(name string) (float64, bool) {
+	info, ok := e.Constant(name)
+	if !ok && e.CaseInsensitiveFunctions {
+		constants := e.Constants()
+		i := slices.IndexFunc(constants, func(c ConstantInfo) bool {
+			return strings.EqualFold(c.Name, name)
+		})
+		if i >= 0 {
+			return constants[i].Value, true
+		}
+	}
+	return info.Value, ok
+}
@@ -0,0 +1,126 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestCaseInsensitiveFunctions(t *testing.T) {
+	tests := []struct {
+		expression string
+		vars       map[string]float64
+		want       float64
+	}{
+		{"SIN(0)", nil, 0},
+		{"Sqrt(4)", nil, 2},
+		{"2 * PI", nil, 2 * math.Pi},
+		{"Pi", nil, math.Pi},
+		{"E", nil, math.E},
+		{"Phi", nil, 1.5},
+		// an exact match wins
+		{"Kb", nil, 2},
+		{"kB", nil, 1},
+		// variables stay case-sensitive and take precedence
+		{"PI", map[string]float64{"PI": 3}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e := NewEvaluator(Options{CaseInsensitiveFunctions: true})
+			for name, value := range map[string]float64{"phi": 1.5, "kB": 1, "Kb": 2} {
+				if err := e.RegisterConstant(name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := e.EvaluateWith(tt.expression, tt.vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaseSensitiveByDefault(t *testing.T) {
+	for _, expression := range []string{"SIN(0)", "PI", "x + X"} {
+		t.Run(expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateWith(expression, map[string]float64{"x": 1})
+			if err == nil {
+				t.Errorf("got %v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestConstantInfo(t *testing.T) {
+	e := NewEvaluator(Options{})
+	light := ConstantInfo{Name: "c", Value: 299792458, Description: "speed of light in vacuum", Unit: "m/s"}
+	if err := e.RegisterConstantInfo(light); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.RegisterConstant("g", 9.80665); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, ok := e.Constant("c"); !ok || got != light {
+		t.Errorf("c got %+v, %v, want %+v", got, ok, light)
+	}
+	if got, ok := e.Constant("g"); !ok || got.Value != 9.80665 || got.Description != "" || got.Unit != "" {
+		t.Errorf("g got %+v, %v, want no metadata", got, ok)
+	}
+	if got, ok := e.Constant("pi"); !ok || got.Value != math.Pi || got.Description == "" {
+		t.Errorf("pi got %+v, %v, want a described default", got, ok)
+	}
+	if _, ok := e.Constant("missing"); ok {
+		t.Error("missing constant found")
+	}
+
+	// evaluations only use the value
+	got, err := e.EvaluateExpression("2 * c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 2*299792458 {
+		t.Errorf("2 * c got %v", got)
+	}
+
+	var names []string
+	for _, info := range e.Constants() {
+		names = append(names, info.Name)
+	}
+	if want := []string{"c", "e", "g", "nan", "pi"}; !slices.Equal(names, want) {
+		t.Errorf("Constants got %v, want %v", names, want)
+	}
+
+	for _, info := range []ConstantInfo{{Name: "1x"}, {Name: "sqrt"}} {
+		if err := e.RegisterConstantInfo(info); err == nil {
+			t.Errorf("%s registered without error", info.Name)
+		}
+	}
+}
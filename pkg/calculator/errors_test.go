@@ -0,0 +1,65 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSyntaxErrorPosition(t *testing.T) {
+	tests := []struct {
+		expression string
+		msg        string
+		pos        int
+	}{
+		{"3 + * 4", "unexpected *", 4},
+		{"1 $ 2", "invalid operator: $", 2},
+		{"1 + 2)", "mismatched parentheses", 5},
+		{"1 2", "too much numbers without operator between them", 2},
+		{"√2", "invalid operator: √", 0},
+		// rune offsets, ² takes two bytes
+		{"3² $ 1", "invalid operator: $", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("got %v, want a syntax error", err)
+			}
+			if syntaxErr.Msg != tt.msg || syntaxErr.Pos != tt.pos {
+				t.Errorf("got %q at %d, want %q at %d", syntaxErr.Msg, syntaxErr.Pos, tt.msg, tt.pos)
+			}
+		})
+	}
+}
+
+func TestSyntaxErrorMessage(t *testing.T) {
+	err := syntaxError(4, "unexpected %s", "*")
+	if err.Error() != "unexpected * at position 4" {
+		t.Errorf("got %q", err.Error())
+	}
+}
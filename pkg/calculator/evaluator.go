@@ -25,214 +25,941 @@
 package calculator
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"html"
+	"io"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
-type tokenType string
+// TokenType is the kind of a Token
+type TokenType string
 
 const (
 	/*define token types*/
 
-	number     tokenType = "NUMBER"
-	operator   tokenType = "OPERATOR"
-	leftParen  tokenType = "LEFT_PAREN"
-	rightParen tokenType = "RIGHT_PAREN"
-	eof        tokenType = "EOF"
+	Number     TokenType = "NUMBER"
+	Operator   TokenType = "OPERATOR"
+	LeftParen  TokenType = "LEFT_PAREN"
+	RightParen TokenType = "RIGHT_PAREN"
+	Comma      TokenType = "COMMA"
+	Variable   TokenType = "VARIABLE"
+	EOF        TokenType = "EOF"
+
+	// Question and Colon separate the condition and branches of cond ? a : b
+	Question TokenType = "QUESTION"
+	Colon    TokenType = "COLON"
 )
 
+// Evaluator evaluates expressions. The zero value is ready to use with
+// the operators of NewOperatorEvaluatorFactory.
+//
+// Expressions may be evaluated from several goroutines at once, as long as
+// the fields are not changed and no functions, constants or operators are
+// defined meanwhile.
 type Evaluator struct {
+	// OperatorEvaluatorFactory provides the operators and functions,
+	// defaults to NewOperatorEvaluatorFactory
 	OperatorEvaluatorFactory OperatorEvaluatorFactory
+
+	// AngleMode is the angle unit used by trigonometric functions,
+	// defaults to Radians
+	AngleMode AngleMode
+
+	// CaseInsensitiveFunctions allows calling functions and using constants
+	// in any case, like SIN(0), Sqrt(4) or 2 * PI. Variable names stay
+	// case-sensitive.
+	CaseInsensitiveFunctions bool
+
+	// NoPrecedence evaluates infix operators strictly from left to right,
+	// like a simple calculator, so 2 + 3 * 4 is 20. This includes the
+	// otherwise right-associative ^, 2 ^ 3 ^ 2 is then 64 rather than 512.
+	NoPrecedence bool
+
+	// InputBase is the base of number literals, defaults to 10.
+	//
+	// Leading zeros never change the base, 010 is ten unless InputBase is 8.
+	// In base 10 a literal in another base is written with a prefix, 0x
+	// for hexadecimal, 0o for octal and 0b for binary, e.g. 0xff or 0b1010.
+	//
+	// In bases above 10 the letter digits make a number, e.g. ff + 1 is 256
+	// in base 16, so a name made up of only such digits is a number rather
+	// than a variable, unless it is a function. Number literals then have
+	// no exponent, but may have a radix point, e.g. 1.8 is 1.5 in base 16,
+	// as may prefixed literals like 0o1.4.
+	InputBase int
+
+	// GammaFactorial extends the factorial of a non-integer x to gamma(x+1),
+	// otherwise it is an error
+	GammaFactorial bool
+
+	// ZeroPowZero controls the result of 0^0, defaults to ZeroPowZeroOne
+	ZeroPowZero ZeroPowZeroPolicy
+
+	// NaNPolicy decides whether NaN propagates through operations or is
+	// an error, defaults to NaNPropagate
+	NaNPolicy NaNPolicy
+
+	// DomainErrors makes an argument outside the domain of a function an
+	// error naming the function and argument, e.g. for asin(2) or log(-1),
+	// instead of a NaN result, and tan(90) in degrees instead of infinity
+	DomainErrors bool
+
+	// MaxMagnitude is the largest absolute value of a result, a larger one
+	// is an overflow error. Zero means unlimited.
+	MaxMagnitude float64
+
+	// UnescapeEntities decodes HTML entities like &lt; and &amp; before
+	// tokenizing, for expressions pasted from web pages. Token offsets are
+	// then in the decoded expression.
+	UnescapeEntities bool
+
+	// RoundOperation rounds the result of every operation before the next
+	// one uses it, e.g. to emulate hardware of limited precision with
+	//
+	//	func(v float64) float64 { return RoundSignificant(v, 7) }
+	//
+	// Results accumulate differently than when only the final one is rounded.
+	RoundOperation func(float64) float64
+
+	// Precision rounds the final result to this number of decimal places,
+	// e.g. 0.1 + 0.2 is 0.3 rather than 0.30000000000000004 with 2.
+	// Zero keeps the full precision, round() rounds to a whole number.
+	Precision int
+
+	// StrictParentheses requires functions to be called with parentheses,
+	// so that sqrt(4) is accepted but sqrt 4 is an error
+	StrictParentheses bool
+
+	// MaxCallDepth limits the nesting of calls to user defined functions,
+	// so that a recursive definition like f(x) = f(x) fails instead of
+	// running forever. Defaults to 100 when zero.
+	MaxCallDepth int
+
+	// FortranExponent accepts d or D as the exponent marker as well, like
+	// 1.5D3. Off by default to not clash with a variable named d. The
+	// token of such a literal has its marker replaced by e.
+	FortranExponent bool
+
+	// CaretXor makes ^ the bitwise xor of integers as in C, the power is
+	// then written with **, e.g. 5 ^ 1 is 4 and 2 ** 10 is 1024
+	CaretXor bool
+
+	// Clock returns the current time for now(), defaults to time.Now.
+	// A fixed clock makes evaluations deterministic, e.g. in tests.
+	Clock func() time.Time
+
+	// Trace receives the tokens, reverse polish notation and each operation
+	// of an evaluation for debugging, nothing is traced when nil
+	Trace io.Writer
+
+	// numberParser overrides parseNumber for number literals when set
+	numberParser func(string) (float64, error)
+	// auditHook receives every operation when set, see SetAuditHook
+	auditHook func(op string, operands []float64, result float64)
+
+	// warnings of the last evaluation to finish, guarded by mu
+	warnings []string
+	mu       sync.Mutex
+
+	// defaultFactory is used when OperatorEvaluatorFactory is nil
+	defaultFactory     OperatorEvaluatorFactory
+	defaultFactoryOnce sync.Once
+
+	// functions defined by EvaluateScript
+	functions map[string]*userFunction
+	// constants registered by RegisterConstant
+	constants map[string]ConstantInfo
+}
+
+// factory returns the OperatorEvaluatorFactory, or the default one created
+// once for a zero value Evaluator, without assigning the field so that
+// concurrent evaluations do not race
+func (e *Evaluator) factory() OperatorEvaluatorFactory {
+	if e.OperatorEvaluatorFactory != nil {
+		return e.OperatorEvaluatorFactory
+	}
+	e.defaultFactoryOnce.Do(func() {
+		e.defaultFactory = NewOperatorEvaluatorFactory()
+	})
+	return e.defaultFactory
+}
+
+// operator resolves an operator or function, user defined functions
+// taking precedence over the factory
+func (e *Evaluator) operator(name string) OperatorEvaluator {
+	if name == "^" && e.CaretXor {
+		name = "xor"
+	}
+	if function, ok := e.functions[name]; ok {
+		return function
+	}
+	return e.factory().Create(name)
+}
+
+func (e *Evaluator) isOperator(name string) bool {
+	_, ok := e.functions[name]
+	return ok || e.factory().IsValid(name)
+}
+
+// SetNumberParser replaces the parser used for number literals, e.g. to
+// accept locale formats. The tokenizer still decides where a number starts
+// and ends, the parser only converts its text to a value. Symbols that are
+// no operator right before a number are part of it, e.g. the parser gets
+// $5 from 2*$5.
+//
+// Passing nil restores the default parser.
+func (e *Evaluator) SetNumberParser(parser func(string) (float64, error)) {
+	e.numberParser = parser
+}
+
+// SetAuditHook sets a function called with every operator or function
+// applied, in the order they are evaluated, e.g. to keep an audit log.
+// The operands are a copy the hook may keep. Calls of user defined
+// functions are reported along with the operations of their bodies.
+//
+// Passing nil removes the hook.
+func (e *Evaluator) SetAuditHook(hook func(op string, operands []float64, result float64)) {
+	e.auditHook = hook
+}
+
+func (e *Evaluator) parseNumber(input string) (float64, error) {
+	if e.numberParser != nil {
+		return e.numberParser(input)
+	}
+	if e.isDecimalInput() {
+		return parseNumber(input)
+	}
+	if e.InputBase < 2 || e.InputBase > 36 {
+		return 0, fmt.Errorf("unsupported input base %d", e.InputBase)
+	}
+	num, err := parseRadix(input, e.InputBase)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %s in base %d", input, e.InputBase)
+	}
+	return num, nil
+}
+
+func (e *Evaluator) isDecimalInput() bool {
+	return e.InputBase == 0 || e.InputBase == 10
+}
+
+// isInputDigit returns true if c is a letter used as a digit by the InputBase
+func (e *Evaluator) isInputDigit(c char) bool {
+	if e.InputBase <= 10 {
+		return false
+	}
+	lower := c | 0x20
+	return lower >= 'a' && lower < 'a'+char(e.InputBase-10)
+}
+
+// isInputNumber returns true if name only consists of digits of the InputBase
+func (e *Evaluator) isInputNumber(name string) bool {
+	for _, c := range name {
+		if !char(c).isDigit() && !e.isInputDigit(char(c)) {
+			return false
+		}
+	}
+	return e.InputBase > 10
+}
+
+// Token is a lexical token of an expression
+type Token struct {
+	Type  TokenType
+	Value string
+	// Start and End are the rune offsets of the token in the expression,
+	// End is exclusive
+	Start int
+	End   int
+	// args is the number of arguments of a function call,
+	// set when converting to reverse polish notation
+	args int
+	// target is the index a ? or : of the reverse polish notation jumps to
+	target int
+}
+
+// isOperand returns true if the token is a value rather than an operator
+func (t Token) isOperand() bool {
+	return t.Type == Number || t.Type == Variable
+}
+
+// Args returns the number of arguments of a function call token returned
+// by ToRPN, which is not known from the reverse polish notation otherwise
+func (t Token) Args() int {
+	return t.args
+}
+
+func (t Token) String() string {
+	return fmt.Sprintf("%s('%s')[%d-%d]", t.Type, t.Value, t.Start, t.End)
+}
+
+// Tokens splits the expression into tokens, e.g. for syntax highlighting.
+//
+// A unary minus is returned as the operator neg, with the offsets of the -
+// typed, and a percent sign as percent. Returns an error if the expression
+// is invalid as evaluating it would.
+func (e *Evaluator) Tokens(expression string) ([]Token, error) {
+	return e.tokenize(expression)
 }
 
-type token struct {
-	tokenType tokenType
-	value     string
-	start     uint16
-	end       uint16
+// ToRPN returns the tokens of the expression in reverse polish notation,
+// the order they are evaluated in, e.g. 3 + 4 * 2 is 3 4 2 * +. A
+// conditional keeps its ? and : between the condition and branches, so
+// that only one branch is evaluated: the ? skips the first branch if the
+// condition is 0 and the : skips the second one.
+func (e *Evaluator) ToRPN(expression string) ([]Token, error) {
+	tokens, err := e.tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	return e.toReversePolishNotation(tokens)
 }
 
-func (t token) String() string {
-	return fmt.Sprintf("%s('%s')[%d-%d]", t.tokenType, t.value, t.start, t.end)
+// entityOperators maps the characters of entities like &minus; and &times;
+// to the operators they stand for
+var entityOperators = strings.NewReplacer("−", "-", "×", "*", "÷", "/")
+
+func (e *Evaluator) tokenize(input string) ([]Token, error) {
+	if e.UnescapeEntities {
+		input = entityOperators.Replace(html.UnescapeString(input))
+	}
+	tokens, err := e.lex(input)
+	if err != nil {
+		return nil, err
+	}
+	tokens = e.percentSigns(tokens)
+	tokens = e.unarySigns(tokens)
+	tokens = e.implicitMultiplication(tokens)
+
+	err = e.validate(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
 }
 
-func (e *Evaluator) tokenize(input string) ([]token, error) {
-	iterator := 0
-	var tokens []token
+// lex splits the input into tokens without validating their order
+func (e *Evaluator) lex(input string) ([]Token, error) {
+	var tokens []Token
+	reader := bufio.NewReader(strings.NewReader(input))
+	err := e.lexReader(reader, func(t Token) error {
+		tokens = append(tokens, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
 
+// lexReader reads the input a rune at a time and passes each token to emit
+// as soon as the rune after it is read, peeking only at the few bytes
+// after a base prefix or exponent marker. Stops at the first error from
+// reading, lexing or emit.
+func (e *Evaluator) lexReader(reader *bufio.Reader, emit func(Token) error) error {
+	// offset is the rune offset of c, numberStart and operatorStart the
+	// offsets of the first rune in the builders
+	offset, numberStart, operatorStart := -1, 0, 0
 	operatorBuilder := strings.Builder{}
 	numberBuilder := strings.Builder{}
 
-	// TODO: fix space handling
-	visitNumber := func(index int) {
+	visitNumber := func() error {
 		if numberBuilder.Len() == 0 {
-			return
+			return nil
 		}
 		curNumber := numberBuilder.String()
 		numberBuilder.Reset()
-		tokens = append(tokens, token{
-			tokenType: number,
-			value:     curNumber,
-			start:     uint16(iterator),
-			end:       uint16(index),
+		return emit(Token{
+			Type:  Number,
+			Value: curNumber,
+			Start: numberStart,
+			End:   offset,
 		})
-		iterator = index + len(curNumber)
 	}
 
-	visitOperator := func(index int) error {
+	visitOperator := func() error {
 		if operatorBuilder.Len() == 0 {
 			return nil
 		}
 		op := operatorBuilder.String()
 		operatorBuilder.Reset()
-		segments, err := e.symbolSegments(op, index)
+		segments, err := e.symbolSegments(op, operatorStart)
 		if err != nil {
 			return err
 		}
-		tokens = append(tokens, segments...)
-		iterator += len(op)
+		for _, segment := range segments {
+			if err := emit(segment); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
+	visitBoth := func() error {
+		if err := visitNumber(); err != nil {
+			return err
+		}
+		return visitOperator()
+	}
+	writeNumber := func(c rune) {
+		if numberBuilder.Len() == 0 {
+			numberStart = offset
+		}
+		numberBuilder.WriteRune(c)
+	}
+	writeOperator := func(c rune) {
+		if operatorBuilder.Len() == 0 {
+			operatorStart = offset
+		}
+		operatorBuilder.WriteRune(c)
+	}
+	// peek returns the byte i places after the rune read, 0 past the end
+	peek := func(i int) byte {
+		ahead, _ := reader.Peek(i + 1)
+		if len(ahead) <= i {
+			return 0
+		}
+		return ahead[i]
+	}
 
-	for index, c := range input {
+	for {
+		c, _, err := reader.ReadRune()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
 		cur := char(c)
+		offset++
 
 		switch {
-		case cur.isNumber():
+		case (cur.isDigit() || c == '_') && numberBuilder.Len() == 0 && endsWithName(operatorBuilder.String()):
+			// digits and underscores following letters are part of a name,
+			// e.g. exp10 or x_1
+			writeOperator(c)
+		case c == '.' && numberBuilder.Len() == 0 && operatorBuilder.Len() > 0 &&
+			e.isInputNumber(operatorBuilder.String()) && !e.isOperator(operatorBuilder.String()):
+			// the letter digits before the radix point were read as a name,
+			// e.g. ff.8 in base 16
+			numberStart = operatorStart
+			numberBuilder.WriteString(operatorBuilder.String())
 			numberBuilder.WriteRune(c)
-			err := visitOperator(index)
-			if err != nil {
-				return nil, err
+			operatorBuilder.Reset()
+		case cur.isNumber():
+			if prefix := e.numberPrefix(operatorBuilder.String()); numberBuilder.Len() == 0 && prefix != "" {
+				symbols := operatorBuilder.String()
+				operatorBuilder.Reset()
+				operatorBuilder.WriteString(strings.TrimSuffix(symbols, prefix))
+				if err := visitOperator(); err != nil {
+					return err
+				}
+				numberStart = offset - utf8.RuneCountInString(prefix)
+				numberBuilder.WriteString(prefix)
+			}
+			writeNumber(c)
+			if err := visitOperator(); err != nil {
+				return err
 			}
 		case cur.isParen():
-			var t tokenType
+			var t TokenType
 			if cur.isLeftParen() {
-				t = leftParen
+				t = LeftParen
 			} else {
-				t = rightParen
+				t = RightParen
 			}
-			visitNumber(index)
-			err := visitOperator(index)
+			if err := visitBoth(); err != nil {
+				return err
+			}
+			err := emit(Token{
+				Type:  t,
+				Value: string(cur),
+				Start: offset,
+				End:   offset + 1,
+			})
 			if err != nil {
-				return nil, err
+				return err
 			}
-			tokens = append(tokens, token{
-				tokenType: t,
-				value:     string(cur),
-				start:     uint16(iterator),
-				end:       uint16(index),
+		case separators[c] != "":
+			if err := visitBoth(); err != nil {
+				return err
+			}
+			err := emit(Token{
+				Type:  separators[c],
+				Value: string(cur),
+				Start: offset,
+				End:   offset + 1,
 			})
-			iterator++
-		case cur == ' ':
-			if numberBuilder.Len() > 0 {
-				visitNumber(index)
-				break
+			if err != nil {
+				return err
 			}
-			if operatorBuilder.Len() > 0 {
-				err := visitOperator(index)
-				if err != nil {
-					return nil, err
-				}
-				break
+		case numberBuilder.String() == "0" && e.isDecimalInput() && isRadixPrefix(c, peek):
+			// a base prefix like the o of 0o17
+			writeNumber(c)
+		case hasRadixPrefix(numberBuilder.String()) && cur.isLetter():
+			// the letter digits of a prefixed literal, e.g. 0xff
+			writeNumber(c)
+		case numberBuilder.Len() > 0 && e.isInputDigit(cur):
+			writeNumber(c)
+		case numberBuilder.Len() > 0 && e.isDecimalInput() && isExponent(numberBuilder.String(), c, peek, e.FortranExponent):
+			// scientific notation, e.g. 1e3 or 2.5E-4
+			if cur == 'd' || cur == 'D' {
+				// read as e so the literal parses as any other
+				c = 'e'
+			}
+			writeNumber(c)
+		case unicode.IsSpace(c):
+			// spaces, tabs and newlines only separate tokens
+			if err := visitBoth(); err != nil {
+				return err
 			}
-			iterator++
 		default:
-			visitNumber(index)
-			operatorBuilder.WriteRune(c)
+			if err := visitNumber(); err != nil {
+				return err
+			}
+			// names and symbols are separate tokens, e.g. 2*x
+			if operatorBuilder.Len() > 0 && cur.isLetter() != endsWithName(operatorBuilder.String()) {
+				if err := visitOperator(); err != nil {
+					return err
+				}
+			}
+			writeOperator(c)
 		}
 	}
-	visitNumber(len(input))
-	err := visitOperator(len(input))
-	if err != nil {
-		return nil, err
+	offset++
+	return visitBoth()
+}
+
+// separators maps the characters that are tokens on their own, besides
+// parentheses, to their type
+var separators = map[rune]TokenType{
+	',': Comma,
+	'?': Question,
+	':': Colon,
+}
+
+// percentSigns turns a % that is not followed by an operand into the
+// suffix operator percent, so that 50% is 0.5 and 200 * 10% is 20, while
+// 7 % 3 remains the remainder. A % before an operator is a percent, so
+// 50% - 3 is -2.5, unless the operator is a sign written directly before
+// an operand, so 7 % -3 remains the remainder 1.
+func (e *Evaluator) percentSigns(tokens []Token) []Token {
+	if !e.isOperator("percent") {
+		return tokens
+	}
+	for i, t := range tokens {
+		if t.Type != Operator || t.Value != "%" || i == 0 {
+			continue
+		}
+		if !e.startsOperand(tokens, i+1) && !e.signsOperand(tokens, i+1) {
+			tokens[i].Value = "percent"
+		}
 	}
+	return tokens
+}
 
-	err = e.validate(tokens)
-	if err != nil {
-		return nil, err
+// signsOperand returns true if the token at index i is a sign written
+// directly before an operand, like the - of 7 % -3 but not of 50% - 3
+func (e *Evaluator) signsOperand(tokens []Token, i int) bool {
+	if i+1 >= len(tokens) {
+		return false
 	}
+	t := tokens[i]
+	_, unary := unaryOperators[t.Value]
+	return t.Type == Operator && (unary || t.Value == "+") &&
+		tokens[i+1].Start == t.End && e.startsOperand(tokens, i+1)
+}
 
-	return tokens, nil
+// unaryOperators maps the signs with a unary form to their prefix operator
+var unaryOperators = map[string]string{
+	"-": "neg",
+	"±": "plusminus",
 }
 
-func (e *Evaluator) validate(tokens []token) error {
-	if len(tokens) == 0 {
-		return fmt.Errorf("no tokens found")
+// internalOperators maps the operators the tokenizer turns signs into to
+// the sign typed. Their names are not operators when typed, e.g. 50 percent
+// is 50 times a variable named percent.
+var internalOperators = map[string]string{
+	"neg":       "-",
+	"plusminus": "±",
+	"percent":   "%",
+}
+
+// isTypedOperator returns true if op typed in an expression is an operator
+// or function
+func (e *Evaluator) isTypedOperator(op string) bool {
+	_, internal := internalOperators[op]
+	return !internal && e.isOperator(op)
+}
+
+// unarySigns turns a unary - or ± into its prefix operator and drops a
+// unary +, so that -5, 3 * -2, -(1 + 2) and 2 - -3 are accepted. A sign
+// is unary at the start, after a left parenthesis or comma, or after
+// another operator except a suffix one.
+//
+// neg binds looser than ^, so -2^2 is -(2^2) = -4 as in written math.
+func (e *Evaluator) unarySigns(tokens []Token) []Token {
+	// from right to left, deleting a + does not move the tokens before it
+	for i := len(tokens) - 1; i >= 0; i-- {
+		t := tokens[i]
+		prefix, ok := unaryOperators[t.Value]
+		if t.Type != Operator || !ok && t.Value != "+" ||
+			!e.isUnary(tokens, i) {
+			continue
+		}
+		if t.Value == "+" {
+			tokens = slices.Delete(tokens, i, i+1)
+			continue
+		}
+		if e.isOperator(prefix) {
+			tokens[i].Value = prefix
+		}
 	}
-	if tokens[0].tokenType == operator {
-		return fmt.Errorf("expression cannot start with an operator")
+	return tokens
+}
+
+// isUnary returns true if the operator at index i has no left operand
+func (e *Evaluator) isUnary(tokens []Token, i int) bool {
+	if i == 0 {
+		return true
 	}
-	if tokens[len(tokens)-1].tokenType == operator {
-		return fmt.Errorf("expression cannot end with an operator")
+	switch previous := tokens[i-1]; previous.Type {
+	case LeftParen, Comma, Question, Colon:
+		return true
+	case Operator:
+		return e.operator(previous.Value).Type() != Suffix
+	}
+	return false
+}
+
+// implicitMultiplication inserts a * between a number or a right
+// parenthesis and an operand directly following it, so that 2(3+4),
+// (1+2)(3+4), (1+2)3, 2pi and 2sqrt(4) are products. The inserted * is
+// empty, both its offsets are the start of the following token.
+//
+// Two numbers like 2 3 are rather a typo and still an error, as is a
+// variable followed by a parenthesis, which is a call to a missing function.
+func (e *Evaluator) implicitMultiplication(tokens []Token) []Token {
+	if !e.isOperator("*") {
+		return tokens
+	}
+	for i := len(tokens) - 2; i >= 0; i-- {
+		t, next := tokens[i], tokens[i+1]
+		if t.Type != Number && t.Type != RightParen ||
+			t.Type == Number && next.Type == Number ||
+			!e.startsOperand(tokens, i+1) {
+			continue
+		}
+		tokens = slices.Insert(tokens, i+1, Token{
+			Type:  Operator,
+			Value: "*",
+			Start: next.Start,
+			End:   next.Start,
+		})
+	}
+	return tokens
+}
+
+func (e *Evaluator) validate(tokens []Token) error {
+	if len(tokens) == 0 {
+		return syntaxError(0, "no tokens found")
+	}
+	for _, t := range tokens {
+		// tokens may be built by the caller rather than lexed
+		if t.Type == Operator && !e.isOperator(t.Value) {
+			return syntaxError(t.Start, "invalid operator: %s", t.Value)
+		}
 	}
 	for i, t := range tokens {
-		// Find two connected numbers without an operator between them
-		// means the expression is invalid
-		if t.tokenType == number {
-			if i+1 < len(tokens) && tokens[i+1].tokenType == number {
-				return fmt.Errorf("too much numbers without operator between them")
+		if t.Type == Operator {
+			if err := e.validateOperator(tokens, i); err != nil {
+				return err
 			}
 		}
+		// only a function call may have nothing between its parentheses
+		if t.Type == LeftParen && i+1 < len(tokens) && tokens[i+1].Type == RightParen &&
+			(i == 0 || tokens[i-1].Type != Operator || e.operator(tokens[i-1].Value).Type() != Function) {
+			return syntaxError(t.Start, "empty parentheses")
+		}
+		if (t.Type == Question || t.Type == Colon) &&
+			(!e.endsOperand(tokens, i-1) || !e.startsOperand(tokens, i+1)) {
+			return syntaxError(t.Start, "unexpected %s", t.Value)
+		}
+		// an operand directly followed by another one, e.g. 2 3 or (1)(2)
+		if e.endsOperand(tokens, i) && e.startsOperand(tokens, i+1) {
+			next := tokens[i+1]
+			if t.Type == Variable && next.Type == LeftParen {
+				return syntaxError(t.Start, "%s is not a function", t.Value)
+			}
+			return syntaxError(next.Start, "too much numbers without operator between them")
+		}
+	}
+	return nil
+}
+
+// validateOperator checks the operands around the operator at index i
+func (e *Evaluator) validateOperator(tokens []Token, i int) error {
+	t := tokens[i]
+	operatorType := e.operator(t.Value).Type()
+	if operatorType == Function {
+		if !e.startsOperand(tokens, i+1) {
+			return syntaxError(t.Start, "function %s missing argument", t.Value)
+		}
+		if e.StrictParentheses && tokens[i+1].Type != LeftParen {
+			return syntaxError(t.Start, "function %s must be called with parentheses, like %s(x)", t.Value, t.Value)
+		}
+		return nil
+	}
+	if operatorType == Infix || operatorType == Suffix {
+		if i == 0 {
+			return syntaxError(t.Start, "expression cannot start with an operator")
+		}
+		if !e.endsOperand(tokens, i-1) {
+			return syntaxError(t.Start, "unexpected operator %s", t.Value)
+		}
+	}
+	if operatorType == Infix || operatorType == Prefix {
+		if i+1 == len(tokens) {
+			return syntaxError(t.Start, "expression cannot end with an operator")
+		}
+		if next := tokens[i+1]; !e.startsOperand(tokens, i+1) {
+			return syntaxError(next.Start, "unexpected %s", next.Value)
+		}
 	}
 	return nil
 }
 
-func (e *Evaluator) symbolSegments(op string, index int) ([]token, error) {
-	if e.OperatorEvaluatorFactory.IsValid(op) {
-		return []token{
+// endsOperand returns true if the token at index i can end an operand,
+// it is a value, a right parenthesis or a suffix operator
+func (e *Evaluator) endsOperand(tokens []Token, i int) bool {
+	if i < 0 || i >= len(tokens) {
+		return false
+	}
+	switch t := tokens[i]; t.Type {
+	case Number, Variable, RightParen:
+		return true
+	case Operator:
+		return e.operator(t.Value).Type() == Suffix
+	}
+	return false
+}
+
+// startsOperand returns true if the token at index i can start an operand,
+// it is a value, a left parenthesis, a function or a prefix operator
+func (e *Evaluator) startsOperand(tokens []Token, i int) bool {
+	if i >= len(tokens) {
+		return false
+	}
+	switch t := tokens[i]; t.Type {
+	case Number, Variable, LeftParen:
+		return true
+	case Operator:
+		operatorType := e.operator(t.Value).Type()
+		return operatorType == Function || operatorType == Prefix
+	}
+	return false
+}
+
+// symbolSegments turns a run of letters or symbols starting at the rune
+// offset start into tokens
+func (e *Evaluator) symbolSegments(op string, start int) ([]Token, error) {
+	end := start + utf8.RuneCountInString(op)
+	if e.isTypedOperator(op) {
+		return []Token{
 			{
-				tokenType: operator,
-				value:     op,
-				start:     uint16(index),
-				end:       uint16(index),
+				Type:  Operator,
+				Value: op,
+				Start: start,
+				End:   end,
 			},
 		}, nil
 	}
-	tokens := make([]token, 0)
-
-	tmpIdx := index
-	operatorBuilder := strings.Builder{}
-	for i, c := range op {
-		if c != ' ' {
-			operatorBuilder.WriteRune(c)
-		}
-		tmpIdx = index + i
-		curOp := operatorBuilder.String()
-		if e.OperatorEvaluatorFactory.IsValid(curOp) {
-			tokens = append(tokens, token{
-				tokenType: operator,
-				value:     curOp,
-				start:     uint16(index),
-				end:       uint16(tmpIdx),
-			})
-			operatorBuilder.Reset()
-		} else if c == ' ' {
-			return nil, fmt.Errorf("invalid space(s) in number or operator")
+	if endsWithName(op) && char(op[0]).isLetter() {
+		if lower := strings.ToLower(op); e.CaseInsensitiveFunctions &&
+			e.isTypedOperator(lower) {
+			return []Token{
+				{
+					Type:  Operator,
+					Value: lower,
+					Start: start,
+					End:   end,
+				},
+			}, nil
+		}
+		if e.isInputNumber(op) {
+			// letters are digits in bases above 10, e.g. ff in base 16
+			return []Token{
+				{
+					Type:  Number,
+					Value: op,
+					Start: start,
+					End:   end,
+				},
+			}, nil
 		}
+		// a name that is not an operator or function refers to a variable
+		return []Token{
+			{
+				Type:  Variable,
+				Value: op,
+				Start: start,
+				End:   end,
+			},
+		}, nil
 	}
+	tokens := make([]Token, 0)
 
-	if operatorBuilder.Len() > 0 {
-		return nil, fmt.Errorf("invalid operator: %s",
-			operatorBuilder.String())
+	// split the symbols into operators, preferring the longest match
+	// so that e.g. !! is not read as two factorials
+	for i := 0; i < len(op); {
+		length := e.longestOperator(op[i:])
+		if length == 0 {
+			return nil, syntaxError(start+utf8.RuneCountInString(op[:i]), "invalid operator: %s", op[i:])
+		}
+		segmentStart := start + utf8.RuneCountInString(op[:i])
+		tokens = append(tokens, Token{
+			Type:  Operator,
+			Value: op[i : i+length],
+			Start: segmentStart,
+			End:   segmentStart + utf8.RuneCountInString(op[i:i+length]),
+		})
+		i += length
 	}
 
 	return tokens, nil
 }
 
-func (e *Evaluator) toReversePolishNotation(tokens []token) ([]token, error) {
-	stack := make([]token, 0)
-	var result []token
-	for _, t := range tokens {
-		switch t.tokenType {
-		case number:
+// longestOperator returns the length of the longest operator symbol
+// symbols starts with, 0 if none
+func (e *Evaluator) longestOperator(symbols string) int {
+	for j := len(symbols); j > 0; j-- {
+		if e.isTypedOperator(symbols[:j]) {
+			return j
+		}
+	}
+	return 0
+}
+
+// numberPrefix returns the end of the symbols before a number that does
+// not start with an operator, e.g. the $ of 2*$5, which a custom number
+// parser reads as part of the number
+func (e *Evaluator) numberPrefix(symbols string) string {
+	if e.numberParser == nil || endsWithName(symbols) {
+		return ""
+	}
+	for i := 0; i < len(symbols); {
+		length := e.longestOperator(symbols[i:])
+		if length == 0 {
+			return symbols[i:]
+		}
+		i += length
+	}
+	return ""
+}
+
+// call tracks the arguments of a parenthesis group while converting
+// to reverse polish notation
+type call struct {
+	// function is the name of the function whose arguments the parenthesis
+	// opens, empty for grouping
+	function string
+	// start is the position of the function name, or of the parenthesis
+	// for grouping
+	start int
+	args  int
+	// output is the length of the reverse polish notation at the parenthesis
+	output int
+	// list is set for the square brackets of a vector, see EvaluateVector
+	list bool
+}
+
+func (e *Evaluator) precedence(operatorEvaluator OperatorEvaluator) Precedence {
+	if e.NoPrecedence && operatorEvaluator.Type() == Infix {
+		return Normal
+	}
+	return operatorEvaluator.Precedence()
+}
+
+// popsBefore returns true if the operator on the top of the stack must be
+// applied before pushing the incoming one
+func (e *Evaluator) popsBefore(incoming, top OperatorEvaluator) bool {
+	if e.NoPrecedence && incoming.Type() == Infix {
+		return e.precedence(incoming) <= e.precedence(top)
+	}
+	if incoming.Associativity() == RightAssoc {
+		return incoming.Precedence() < top.Precedence()
+	}
+	return incoming.Precedence() <= top.Precedence()
+}
+
+func (e *Evaluator) toReversePolishNotation(tokens []Token) ([]Token, error) {
+	polishNotation, _, err := e.toReversePolishNotationGroups(tokens, false)
+	return polishNotation, err
+}
+
+// toReversePolishNotationGroups converts the tokens to reverse polish
+// notation, also returning the parenthesized groups in it. With lists,
+// square brackets that are not a call are a vector of the comma separated
+// elements, output as an operator [ taking them as arguments.
+func (e *Evaluator) toReversePolishNotationGroups(tokens []Token, lists bool) ([]Token, []group, error) {
+	stack := make([]Token, 0)
+	calls := make([]call, 0)
+	var result []Token
+	var groups []group
+	// a ? or : on the stack has the target of the one output for it, which
+	// the : completing the conditional sets to the end of its last branch
+	pop := func() {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if top.Type == Colon {
+			result[top.target].target = len(result)
+			return
+		}
+		result = append(result, top)
+	}
+	// flush moves the operators up to the innermost parenthesis to the
+	// result, which must not leave a condition without its branches
+	flush := func() error {
+		for len(stack) > 0 && stack[len(stack)-1].Type != LeftParen {
+			if top := stack[len(stack)-1]; top.Type == Question {
+				return syntaxError(top.Start, "missing : after ?")
+			}
+			pop()
+		}
+		return nil
+	}
+	for i, t := range tokens {
+		switch t.Type {
+		case Number, Variable:
 			result = append(result, t)
-		case operator:
-			operatorEvaluator := e.OperatorEvaluatorFactory.Create(t.value)
+		case Operator:
+			operatorEvaluator := e.operator(t.Value)
+			if operatorEvaluator.Type() == Function ||
+				operatorEvaluator.Type() == Prefix {
+				// a function or prefix operator applies to what follows,
+				// nothing before it can be popped yet
+				t.args = 1
+				stack = append(stack, t)
+				break
+			}
+			if operatorEvaluator.Type() == Suffix {
+				// the operand before a suffix operator is already complete
+				result = append(result, t)
+				break
+			}
 			for len(stack) > 0 {
 				top := stack[len(stack)-1]
-				if top.tokenType == operator && operatorEvaluator.Precedence() <=
-					e.OperatorEvaluatorFactory.Create(top.value).Precedence() {
+				if top.Type == Operator && e.popsBefore(operatorEvaluator,
+					e.operator(top.Value)) {
 					result = append(result, top)
 					stack = stack[:len(stack)-1]
 				} else {
@@ -240,122 +967,779 @@ func (e *Evaluator) toReversePolishNotation(tokens []token) ([]token, error) {
 				}
 			}
 			stack = append(stack, t)
-		case leftParen:
+		case LeftParen:
+			c := call{args: 1, start: t.Start, output: len(result)}
+			if i > 0 && tokens[i-1].Type == Operator &&
+				e.operator(tokens[i-1].Value).Type() == Function {
+				c.function = tokens[i-1].Value
+				c.start = tokens[i-1].Start
+			} else {
+				c.list = lists && isBracket(t.Value)
+			}
+			if i+1 < len(tokens) && tokens[i+1].Type == RightParen {
+				c.args = 0
+			}
+			calls = append(calls, c)
 			stack = append(stack, t)
-		case rightParen:
-			for len(stack) != 0 {
-				top := stack[len(stack)-1]
-				stack = stack[:len(stack)-1]
-				if top.tokenType == leftParen {
-					break
-				}
-				result = append(result, top)
+		case Comma:
+			if len(calls) == 0 || calls[len(calls)-1].function == "" && !calls[len(calls)-1].list {
+				return nil, nil, syntaxError(t.Start, "unexpected comma outside of function arguments")
 			}
+			if previous := tokens[i-1]; previous.Type == LeftParen || previous.Type == Comma {
+				return nil, nil, emptyArgument(t, calls[len(calls)-1])
+			}
+			if err := flush(); err != nil {
+				return nil, nil, err
+			}
+			calls[len(calls)-1].args++
+		case RightParen:
+			if len(calls) == 0 {
+				return nil, nil, syntaxError(t.Start, "mismatched parentheses")
+			}
+			if i > 0 && tokens[i-1].Type == Comma {
+				return nil, nil, emptyArgument(t, calls[len(calls)-1])
+			}
+			if err := flush(); err != nil {
+				return nil, nil, err
+			}
+			// the left parenthesis, closed by the same kind
+			if open := stack[len(stack)-1]; isBracket(open.Value) != isBracket(t.Value) {
+				return nil, nil, syntaxError(t.Start, "mismatched %s closing %s", t.Value, open.Value)
+			}
+			stack = stack[:len(stack)-1]
+			c := calls[len(calls)-1]
+			calls = calls[:len(calls)-1]
+			if c.list {
+				result = append(result, Token{
+					Type:  Operator,
+					Value: "[",
+					Start: c.start,
+					End:   t.End,
+					args:  c.args,
+				})
+				break
+			}
+			if c.function == "" {
+				groups = append(groups, group{
+					GroupValue: GroupValue{Start: c.start, End: t.End},
+					first:      c.output,
+					end:        len(result),
+				})
+				break
+			}
+			function := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			function.args = c.args
+			result = append(result, function)
+		case Question:
+			// the condition binds looser than any operator
+			for len(stack) > 0 && stack[len(stack)-1].Type == Operator {
+				pop()
+			}
+			t.target = len(result)
+			result = append(result, t)
+			stack = append(stack, t)
+		case Colon:
+			// complete the branch, including the conditionals nested in it
+			for len(stack) > 0 && (stack[len(stack)-1].Type == Operator ||
+				stack[len(stack)-1].Type == Colon) {
+				pop()
+			}
+			if len(stack) == 0 || stack[len(stack)-1].Type != Question {
+				return nil, nil, syntaxError(t.Start, "unexpected : without ?")
+			}
+			// the ? skips to the other branch, after this :
+			question := stack[len(stack)-1]
+			t.target = len(result)
+			result = append(result, t)
+			result[question.target].target = len(result)
+			stack[len(stack)-1] = t
 		}
 	}
 
-	for len(stack) > 0 {
-		top := stack[len(stack)-1]
-		stack = stack[:len(stack)-1]
-		if top.tokenType == leftParen || top.tokenType == rightParen {
-			return nil, fmt.Errorf("mismatched parentheses")
+	if len(calls) > 0 {
+		c := calls[len(calls)-1]
+		if c.function != "" {
+			return nil, nil, syntaxError(c.start, "unclosed call to %s", c.function)
 		}
-		result = append(result, top)
+		return nil, nil, syntaxError(c.start, "mismatched parentheses")
 	}
-	return result, nil
+	if err := flush(); err != nil {
+		return nil, nil, err
+	}
+	return result, groups, nil
+}
+
+// emptyArgument returns the error of a missing argument or vector element
+// before the comma or right parenthesis t
+func emptyArgument(t Token, c call) error {
+	if c.list {
+		return syntaxError(t.Start, "empty element %d of vector", c.args)
+	}
+	return syntaxError(t.Start, "empty argument %d of function %s", c.args, c.function)
 }
 
 func (e *Evaluator) EvaluateExpression(expression string) (float64, error) {
+	return e.EvaluateWith(expression, nil)
+}
+
+// EvaluateExpressionContext evaluates the expression like EvaluateExpression,
+// returning the error of the context once it is done, e.g. to bound the time
+// spent on user defined functions recursing many times.
+//
+// The context is checked before each operation and within the loop of
+// long running functions like isprime.
+func (e *Evaluator) EvaluateExpressionContext(ctx context.Context, expression string) (float64, error) {
+	e.setWarnings(nil)
 	tokens, err := e.tokenize(expression)
-	fmt.Println(tokens)
 	if err != nil {
 		return 0, err
 	}
+	return e.evaluate(&evaluation{ctx: ctx}, tokens, nil)
+}
+
+// EvaluateWith evaluates the expression with the given variable values,
+// e.g. x*2 + y. A variable is a name that is not an operator, function or
+// constant, starting with a letter followed by letters, digits or
+// underscores. Returns an error if a variable used is missing from vars.
+func (e *Evaluator) EvaluateWith(expression string, vars map[string]float64) (float64, error) {
+	e.setWarnings(nil)
+	tokens, err := e.tokenize(expression)
+	if err != nil {
+		return 0, err
+	}
+	return e.evaluate(&evaluation{ctx: context.Background()}, tokens, vars)
+}
+
+// EvaluateTokens evaluates tokens built by the caller rather than lexed
+// from an expression. The tokens are validated the same way.
+func (e *Evaluator) EvaluateTokens(tokens []Token) (float64, error) {
+	e.setWarnings(nil)
+	err := e.validate(tokens)
+	if err != nil {
+		return 0, err
+	}
+	return e.evaluate(&evaluation{ctx: context.Background()}, tokens, nil)
+}
+
+// EvaluateRestricted evaluates the expression like EvaluateExpression, but
+// returns an error if it applies any of the disabled operators or functions.
+//
+// This allows restricting a single untrusted evaluation, e.g. forbidding
+// ^ and !, without building separate factories. The restriction holds
+// within the user defined functions called too. An operator is disabled
+// by the symbol typed for it, e.g. - also disables the unary minus, and
+// xor also the ^ of CaretXor. Operators in a branch of a conditional not
+// taken are not applied, so they are not reported.
+func (e *Evaluator) EvaluateRestricted(expression string, disabledSymbols []string) (float64, error) {
+	e.setWarnings(nil)
+	tokens, err := e.tokenize(expression)
+	if err != nil {
+		return 0, err
+	}
+	return e.evaluate(&evaluation{ctx: context.Background(), disabled: disabledSymbols}, tokens, nil)
+}
+
+// EstimateCost returns the number of operator and function applications
+// in the expression, e.g. for rate limiting evaluations.
+func (e *Evaluator) EstimateCost(expression string) (int, error) {
+	breakdown, err := e.EstimateCostBreakdown(expression)
+	if err != nil {
+		return 0, err
+	}
+	cost := 0
+	for _, count := range breakdown {
+		cost += count
+	}
+	return cost, nil
+}
+
+// EstimateCostBreakdown returns the number of applications of each operator
+// and function in the expression, so they can be weighted differently.
+func (e *Evaluator) EstimateCostBreakdown(expression string) (map[string]int, error) {
+	tokens, err := e.tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	breakdown := make(map[string]int)
+	for _, t := range tokens {
+		if t.Type == Operator {
+			breakdown[t.Value]++
+		}
+	}
+	return breakdown, nil
+}
+
+// MaxNesting returns the deepest nesting of parentheses in the expression,
+// counting those of function calls, e.g. 0 for 1 + 2 and 2 for (1 + sqrt(4)),
+// so that overly complex input can be rejected.
+func (e *Evaluator) MaxNesting(expression string) (int, error) {
+	tokens, err := e.tokenize(expression)
+	if err != nil {
+		return 0, err
+	}
+	// reports unbalanced parentheses
+	if _, err := e.toReversePolishNotation(tokens); err != nil {
+		return 0, err
+	}
+	depth, maxDepth := 0, 0
+	for _, t := range tokens {
+		switch t.Type {
+		case LeftParen:
+			depth++
+			maxDepth = max(maxDepth, depth)
+		case RightParen:
+			depth--
+		}
+	}
+	return maxDepth, nil
+}
+
+func (e *Evaluator) evaluate(ev *evaluation, tokens []Token, vars map[string]float64) (float64, error) {
 	if len(tokens) == 0 {
 		return 0, fmt.Errorf("no tokens found")
 	}
+	e.trace("tokens: %v", tokens)
 	polishNotation, err := e.toReversePolishNotation(tokens)
 	if err != nil {
 		return 0, err
 	}
+	e.trace("reverse polish notation: %v", polishNotation)
+	return e.runExpression(ev, polishNotation, nil, vars)
+}
+
+// runExpression runs a whole expression rather than the body of a user
+// defined function, checking the final result
+func (e *Evaluator) runExpression(ev *evaluation, polishNotation []Token, operators []OperatorEvaluator, vars map[string]float64) (float64, error) {
+	result, err := e.run(ev, polishNotation, operators, vars)
+	e.setWarnings(ev.warnings)
+	if err != nil {
+		return 0, err
+	}
+	return e.checkFinal(result)
+}
+
+// checkFinal applies the checks of the final result of an expression
+func (e *Evaluator) checkFinal(result float64) (float64, error) {
+	if e.Precision > 0 {
+		result = RoundDecimals(result, e.Precision)
+	}
+	if e.NaNPolicy == NaNError && math.IsNaN(result) {
+		return 0, fmt.Errorf("result is NaN")
+	}
+	if e.MaxMagnitude > 0 && math.Abs(result) > e.MaxMagnitude {
+		return 0, fmt.Errorf("overflow: result %v exceeds the maximum magnitude %v", result, e.MaxMagnitude)
+	}
+	return result, nil
+}
+
+// resolve looks up the evaluator of each operator token ahead of evaluation
+func (e *Evaluator) resolve(polishNotation []Token) []OperatorEvaluator {
+	operators := make([]OperatorEvaluator, len(polishNotation))
+	for i, t := range polishNotation {
+		if t.Type == Operator {
+			operators[i] = e.operator(t.Value)
+		}
+	}
+	return operators
+}
+
+// evaluation is the state of a single evaluation, passed down rather than
+// kept on the Evaluator so that evaluations may run concurrently
+type evaluation struct {
+	ctx context.Context
+	// depth is the current nesting of user defined function calls
+	depth    int
+	warnings []string
+	// groups of the expression whose values are captured, see EvaluateGroups
+	groups []group
+	// disabled are the symbols of the operators and functions that must not
+	// be applied, see EvaluateRestricted
+	disabled []string
+}
+
+func (ev *evaluation) warn(format string, args ...any) {
+	ev.warnings = append(ev.warnings, fmt.Sprintf(format, args...))
+}
+
+// run evaluates the reverse polish notation, also used for the nested
+// evaluations of user defined functions. Operators are looked up as they
+// are met unless already resolved.
+func (e *Evaluator) run(ev *evaluation, polishNotation []Token, operators []OperatorEvaluator, vars map[string]float64) (float64, error) {
 	var stack []float64
-	for _, t := range polishNotation {
-		switch t.tokenType {
-		// TODO: allow negative numbers
-		case number:
-			num, err := parseNumber(t.value)
+	for i := 0; i < len(polishNotation); i++ {
+		if ev.depth == 0 {
+			ev.reach(i, stack)
+		}
+		t := polishNotation[i]
+		switch t.Type {
+		case Number:
+			num, err := e.parseNumber(t.Value)
 			if err != nil {
 				return 0, err
 			}
+			if e.isDecimalInput() && isPrecisionLost(t.Value, num) {
+				ev.warn("precision loss in number %s", t.Value)
+			}
 			stack = append(stack, num)
-		case operator:
-			operatorEvaluator := e.OperatorEvaluatorFactory.Create(t.value)
-			switch operatorEvaluator.Type() {
-			case Function: // Function like sin, sqrt, log, etc., only one operand is required
-				if len(stack) < 1 {
-					return 0, fmt.Errorf("invalid expression")
-				}
-				right := stack[len(stack)-1]
-				stack = stack[:len(stack)-1]
-				result, err := operatorEvaluator.Evaluate(right, 0)
-				if err != nil {
-					return 0, err
-				}
-				stack = append(stack, result)
-			case Infix:
-				if len(stack) < 2 {
-					return 0, fmt.Errorf("invalid expression")
-				}
-				right := stack[len(stack)-1]
-				left := stack[len(stack)-2]
-				stack = stack[:len(stack)-2]
-				result, err := operatorEvaluator.Evaluate(left, right)
-				if err != nil {
-					return 0, err
-				}
-				stack = append(stack, result)
-			case Suffix:
-				if len(stack) < 1 {
-					return 0, fmt.Errorf("invalid expression")
-				}
-				left := stack[len(stack)-1]
-				stack = stack[:len(stack)-1]
-				result, err := operatorEvaluator.Evaluate(left, 0)
-				if err != nil {
+		case Variable:
+			value, ok := vars[t.Value]
+			if !ok {
+				value, ok = e.constant(t.Value)
+			}
+			if !ok {
+				return 0, fmt.Errorf("undefined variable: %s", t.Value)
+			}
+			stack = append(stack, value)
+		case Operator:
+			if err := ev.ctx.Err(); err != nil {
+				return 0, err
+			}
+			if err := e.checkEnabled(ev, t.Value); err != nil {
+				return 0, err
+			}
+			var operatorEvaluator OperatorEvaluator
+			if operators != nil {
+				operatorEvaluator = operators[i]
+			} else {
+				operatorEvaluator = e.operator(t.Value)
+			}
+			if operatorEvaluator.Type() == Function {
+				if err := checkArity(t.Value, operatorEvaluator, t.args); err != nil {
 					return 0, err
 				}
-				stack = append(stack, result)
 			}
+			n := operandCount(t, operatorEvaluator)
+			if len(stack) < n {
+				return 0, fmt.Errorf("invalid expression")
+			}
+			operands := stack[len(stack)-n:]
+			stack = stack[:len(stack)-n]
+			result, err := e.apply(ev, t, operatorEvaluator, operands)
+			if err != nil {
+				return 0, err
+			}
+			// operands share the stack's backing array, check them before
+			// the result overwrites it
+			e.record(t.Value, operands, result)
+			if err := e.checkResult(ev, t.Value, operands, result); err != nil {
+				return 0, err
+			}
+			stack = append(stack, result)
+		case Question:
+			// cond ? a : b evaluates only the branch taken
+			if len(stack) < 1 {
+				return 0, fmt.Errorf("invalid expression")
+			}
+			condition := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if condition == 0 {
+				i = t.target - 1
+			}
+		case Colon:
+			// the end of the first branch, skip the second one
+			i = t.target - 1
 		}
 	}
 
+	if ev.depth == 0 {
+		ev.reach(len(polishNotation), stack)
+	}
+
+	// e.g. caller built tokens leaving no value or several values
+	if len(stack) != 1 {
+		return 0, fmt.Errorf("invalid expression")
+	}
 	return stack[0], nil
 }
 
+// checkEnabled returns an error if the operator is disabled in ev, by the
+// symbol typed for it, e.g. - for neg
+func (e *Evaluator) checkEnabled(ev *evaluation, op string) error {
+	if len(ev.disabled) == 0 {
+		return nil
+	}
+	symbol := op
+	if sign, ok := internalOperators[op]; ok {
+		symbol = sign
+	} else if op == "^" && e.CaretXor {
+		symbol = "xor"
+	}
+	if slices.Contains(ev.disabled, symbol) {
+		return fmt.Errorf("operator %s is disabled", symbol)
+	}
+	return nil
+}
+
+// record traces an operation and reports it to the audit hook
+func (e *Evaluator) record(op string, operands []float64, result float64) {
+	e.trace("%s %v = %v", op, operands, result)
+	if e.auditHook != nil {
+		e.auditHook(op, slices.Clone(operands), result)
+	}
+}
+
+// operandCount returns the number of operands the operator of t takes
+func operandCount(t Token, operatorEvaluator OperatorEvaluator) int {
+	switch operatorEvaluator.Type() {
+	case Function:
+		return t.args
+	case Infix:
+		return 2
+	}
+	return 1
+}
+
+// apply evaluates the operator of t on its operands
+func (e *Evaluator) apply(ev *evaluation, t Token, operatorEvaluator OperatorEvaluator, operands []float64) (float64, error) {
+	var result float64
+	var err error
+	switch operatorEvaluator.Type() {
+	case Function: // Function like sin, sqrt, log, etc., takes its arguments from the call
+		if function, ok := operatorEvaluator.(*userFunction); ok {
+			result, err = function.call(ev, operands)
+			break
+		}
+		if long, ok := operatorEvaluator.(contextEvaluator); ok {
+			result, err = long.evaluateContext(ev.ctx, operands)
+			break
+		}
+		if multiArg, ok := operatorEvaluator.(MultiArgEvaluator); ok {
+			result, err = e.evaluateArgs(t.Value, multiArg, operands)
+			break
+		}
+		result, err = e.evaluateFunction(t.Value, operatorEvaluator, operands[0])
+	case Infix:
+		result, err = e.evaluateInfix(operatorEvaluator, operands[0], operands[1])
+	case Suffix:
+		result, err = e.evaluateSuffix(operatorEvaluator, operands[0])
+	case Prefix:
+		result, err = operatorEvaluator.Evaluate(operands[0], 0)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if e.RoundOperation != nil {
+		result = e.RoundOperation(result)
+	}
+	return result, nil
+}
+
+func checkArity(function string, operatorEvaluator OperatorEvaluator, args int) error {
+	minArgs, maxArgs := 1, 1
+	if multiArg, ok := operatorEvaluator.(MultiArgEvaluator); ok {
+		minArgs, maxArgs = multiArg.Arity()
+	}
+	if args < minArgs || maxArgs >= 0 && args > maxArgs {
+		return fmt.Errorf("function %s expects %s, got %d",
+			function, describeArity(minArgs, maxArgs), args)
+	}
+	return nil
+}
+
+func describeArity(minArgs, maxArgs int) string {
+	switch {
+	case maxArgs < 0:
+		return fmt.Sprintf("at least %d argument(s)", minArgs)
+	case minArgs == maxArgs:
+		return fmt.Sprintf("%d argument(s)", minArgs)
+	}
+	return fmt.Sprintf("%d to %d arguments", minArgs, maxArgs)
+}
+
+func (e *Evaluator) trace(format string, args ...any) {
+	if e.Trace == nil {
+		return
+	}
+	_, _ = fmt.Fprintf(e.Trace, format+"\n", args...)
+}
+
+// Warnings returns the non-fatal problems noticed during the last evaluation,
+// like a NaN result or a number literal losing precision, none if it failed
+// before evaluating anything, e.g. on a syntax error. When evaluating
+// from several goroutines, this is the last evaluation of any of them to
+// finish, evaluate with separate Evaluators to tell the warnings apart.
+func (e *Evaluator) Warnings() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.warnings
+}
+
+func (e *Evaluator) setWarnings(warnings []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.warnings = warnings
+}
+
+func (e *Evaluator) checkResult(ev *evaluation, operator string, operands []float64, result float64) error {
+	if !math.IsNaN(result) {
+		return nil
+	}
+	nanOperand := slices.ContainsFunc(operands, math.IsNaN)
+	if e.NaNPolicy == NaNError {
+		if nanOperand {
+			return fmt.Errorf("%s of NaN", operator)
+		}
+		return fmt.Errorf("%s produced NaN", operator)
+	}
+	if !nanOperand {
+		ev.warn("%s produced NaN", operator)
+	}
+	return nil
+}
+
+// isPrecisionLost returns true if an integer literal cannot be
+// represented exactly as float64
+func isPrecisionLost(literal string, value float64) bool {
+	if strings.ContainsAny(literal, ".eE") || hasRadixPrefix(literal) {
+		return false
+	}
+	literal = strings.TrimLeft(literal, "0")
+	return literal != "" && literal != strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func (e *Evaluator) evaluateInfix(operatorEvaluator OperatorEvaluator, left, right float64) (float64, error) {
+	if _, ok := operatorEvaluator.(powerEvaluator); ok && left == 0 && right == 0 {
+		switch e.ZeroPowZero {
+		case ZeroPowZeroError:
+			return 0, fmt.Errorf("0^0 is undefined")
+		case ZeroPowZeroNaN:
+			return math.NaN(), nil
+		}
+	}
+	return operatorEvaluator.Evaluate(left, right)
+}
+
+func (e *Evaluator) evaluateSuffix(operatorEvaluator OperatorEvaluator, operand float64) (float64, error) {
+	if _, ok := operatorEvaluator.(factorialEvaluator); ok && e.GammaFactorial &&
+		operand != math.Trunc(operand) {
+		return math.Gamma(operand + 1), nil
+	}
+	return operatorEvaluator.Evaluate(operand, 0)
+}
+
+func (e *Evaluator) checkDomain(function string, operatorEvaluator OperatorEvaluator, x float64) error {
+	if domain, ok := operatorEvaluator.(domainEvaluator); ok && e.DomainErrors {
+		if d, in := domain.domain(x); !in {
+			return fmt.Errorf("%s: argument out of domain %s: %v", function, d, x)
+		}
+	}
+	return nil
+}
+
+func (e *Evaluator) evaluateArgs(function string, multiArg MultiArgEvaluator, args []float64) (float64, error) {
+	if clock, ok := multiArg.(clockEvaluator); ok {
+		return clock.evaluateAt(e.now()), nil
+	}
+	// the domain is of the last argument, e.g. x of log(base, x)
+	if len(args) > 0 {
+		if err := e.checkDomain(function, multiArg, args[len(args)-1]); err != nil {
+			return 0, err
+		}
+	}
+	result, err := multiArg.EvaluateArgs(args)
+	if err != nil {
+		return 0, err
+	}
+	if angle, ok := multiArg.(angleEvaluator); ok && angle.inverse() {
+		return e.AngleMode.fromRadians(result), nil
+	}
+	return result, nil
+}
+
+func (e *Evaluator) evaluateFunction(function string, operatorEvaluator OperatorEvaluator, operand float64) (float64, error) {
+	if err := e.checkDomain(function, operatorEvaluator, operand); err != nil {
+		return 0, err
+	}
+	if turn, ok := operatorEvaluator.(turnEvaluator); ok {
+		return turn.evaluateTurn(operand, e.AngleMode.fullTurn()), nil
+	}
+	angle, ok := operatorEvaluator.(angleEvaluator)
+	if !ok {
+		return operatorEvaluator.Evaluate(operand, 0)
+	}
+	if !angle.inverse() {
+		if e.AngleMode != Radians {
+			// reduce in degrees or gradians, which is exact
+			reduced := math.Mod(operand, e.AngleMode.fullTurn())
+			quarter, ok := operatorEvaluator.(quarterTurnEvaluator)
+			if n := reduced / (e.AngleMode.fullTurn() / 4); ok && n == math.Trunc(n) {
+				result := quarter.quarterTurn((int(n) + 4) % 4)
+				// tan(90) in degrees, in radians pi/2 is never exact
+				if math.IsInf(result, 0) && e.DomainErrors {
+					return 0, fmt.Errorf("%s: argument out of domain, asymptote at %v", function, operand)
+				}
+				return result, nil
+			}
+			operand = reduced
+		}
+		return operatorEvaluator.Evaluate(e.AngleMode.toRadians(operand), 0)
+	}
+	result, err := operatorEvaluator.Evaluate(operand, 0)
+	if err != nil {
+		return 0, err
+	}
+	return e.AngleMode.fromRadians(result), nil
+}
+
+func (e *Evaluator) now() time.Time {
+	if e.Clock != nil {
+		return e.Clock()
+	}
+	return time.Now()
+}
+
+// EvaluateInteger evaluates the expression and returns the result as an int64.
+//
+// Returns an error if the result is not a whole number or does not fit in an int64,
+// rather than a truncated value.
+func (e *Evaluator) EvaluateInteger(expression string) (int64, error) {
+	res, err := e.EvaluateExpression(expression)
+	if err != nil {
+		return 0, err
+	}
+	return toInteger(res)
+}
+
+func toInteger(value float64) (int64, error) {
+	if math.IsNaN(value) {
+		return 0, fmt.Errorf("result is not a number")
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which is already out of range
+	if value >= math.MaxInt64 || value < math.MinInt64 {
+		return 0, fmt.Errorf("integer overflow: %s exceeds int64",
+			strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	if value != math.Trunc(value) {
+		return 0, fmt.Errorf("result is not an integer: %s",
+			strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	return int64(value), nil
+}
+
+// isExponent returns true if c continues the number with its exponent
+// part, either the exponent marker followed by a (signed) digit, or the
+// sign right after the marker. peek returns the bytes after c. The marker
+// may be d or D as well if fortran is true.
+func isExponent(number string, c rune, peek func(int) byte, fortran bool) bool {
+	switch c {
+	case 'd', 'D':
+		if !fortran {
+			return false
+		}
+		fallthrough
+	case 'e', 'E':
+		if strings.ContainsAny(number, "eE") {
+			return false
+		}
+		i := 0
+		for peek(i) == '+' || peek(i) == '-' {
+			i++
+		}
+		return peek(i) >= '0' && peek(i) <= '9'
+	case '+', '-':
+		last := number[len(number)-1]
+		return last == 'e' || last == 'E'
+	}
+	return false
+}
+
+// radixPrefixes maps the letter after the 0 of a prefixed literal to its base
+var radixPrefixes = map[byte]int{
+	'x': 16,
+	'o': 8,
+	'b': 2,
+}
+
+// isRadixPrefix returns true if c is the letter of a base prefix followed
+// by a digit or letter, a letter that is not a digit of the base makes the
+// literal malformed rather than a name, e.g. 0xG1
+func isRadixPrefix(c rune, peek func(int) byte) bool {
+	if c >= utf8.RuneSelf {
+		return false
+	}
+	_, ok := radixPrefixes[byte(c)|0x20]
+	next := char(peek(0))
+	return ok && (next.isDigit() || next.isLetter())
+}
+
+// hasRadixPrefix returns true if the number literal starts with a base
+// prefix like 0x
+func hasRadixPrefix(number string) bool {
+	if len(number) < 2 || number[0] != '0' {
+		return false
+	}
+	_, ok := radixPrefixes[number[1]|0x20]
+	return ok
+}
+
 func parseNumber(input string) (float64, error) {
-	if strings.Contains(input, ".") {
+	if hasRadixPrefix(input) {
+		base := radixPrefixes[input[1]|0x20]
+		num, err := parseRadix(input[2:], base)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %s in base %d", input, base)
+		}
+		return num, nil
+	}
+	// leading zeros are decimal, Atoi does not take 010 for octal
+	if strings.ContainsAny(input, ".eE") {
 		return strconv.ParseFloat(input, 64)
 	}
 	atoi, err := strconv.Atoi(input)
 	if err != nil {
-		return 0, nil
+		// out of int range, keep the closest float64
+		return strconv.ParseFloat(input, 64)
 	}
 	return float64(atoi), nil
 }
 
+// parseRadix parses digits in the base with an optional radix point,
+// e.g. 1.4 in base 8 is 1.5
+func parseRadix(digits string, base int) (float64, error) {
+	integer, fraction, _ := strings.Cut(digits, ".")
+	num, err := strconv.ParseInt(integer+fraction, base, 64)
+	if err != nil {
+		return 0, err
+	}
+	return float64(num) / math.Pow(float64(base), float64(len(fraction))), nil
+}
+
 type char uint8
 
 func (c char) isNumber() bool {
 	return c >= '0' && c <= '9' || c == '.'
 }
+func (c char) isDigit() bool {
+	return c >= '0' && c <= '9'
+}
+
+func (c char) isLetter() bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// endsWithName returns true if s ends with a name, which is letters
+// optionally followed by letters, digits and underscores
+func endsWithName(s string) bool {
+	s = strings.TrimRight(s, "0123456789_")
+	return len(s) > 0 && char(s[len(s)-1]).isLetter()
+}
+
+// isParen returns true for parentheses and square brackets, which group
+// the same way
 func (c char) isParen() bool {
-	return c == '(' || c == ')'
+	return c.isLeftParen() || c.isRightParen()
 }
 
 func (c char) isLeftParen() bool {
-	return c == '('
+	return c == '(' || c == '['
 }
 
 func (c char) isRightParen() bool {
-	return c == ')'
+	return c == ')' || c == ']'
+}
+
+// isBracket returns true if the parenthesis token is a square bracket
+func isBracket(paren string) bool {
+	return paren == "[" || paren == "]"
 }
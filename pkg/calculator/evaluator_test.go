@@ -0,0 +1,1676 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestComparisons(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"2 < 3", 1},
+		{"3 < 2", 0},
+		{"3 > 2", 1},
+		{"2 <= 2", 1},
+		{"3 >= 4", 0},
+		{"0.5 == 1 / 2", 1},
+		{"1 != 1", 0},
+		{"5!=3", 1},
+		// arithmetic and bitwise operators bind tighter
+		{"1 + 1 >= 2", 1},
+		{"6 & 3 == 2", 1},
+		{"1 < 2 == 1", 1},
+		{"nan == nan", 0},
+		{"nan != nan", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditional(t *testing.T) {
+	tests := []struct {
+		expression string
+		vars       map[string]float64
+		want       float64
+	}{
+		{"1 > 0 ? 10 : 20", nil, 10},
+		{"1 < 0 ? 10 : 20", nil, 20},
+		{"(2 > 1 ? 3 : 4) * 2", nil, 6},
+		{"1 ? 2 : 3 + 4", nil, 2},
+		{"0 ? 2 : 3 + 4", nil, 7},
+		// nested in either branch, right-associative
+		{"0 ? 1 : 0 ? 2 : 3", nil, 3},
+		{"0 ? 1 : 1 ? 2 : 3", nil, 2},
+		{"1 ? 0 ? 7 : 8 : 9", nil, 8},
+		{"1 ? 1 ? 7 : 8 : 9", nil, 7},
+		{"0 ? 1 ? 7 : 8 : 9", nil, 9},
+		{"max(1 ? 2 : 3, 0 ? 4 : 5)", nil, 5},
+		// the branch not taken is not evaluated
+		{"0 ? 1 / 0 : 5", nil, 5},
+		{"1 ? 5 : 1 / 0", nil, 5},
+		{"x != 0 ? 1 / x : 0", map[string]float64{"x": 0}, 0},
+		{"x != 0 ? 1 / x : 0", map[string]float64{"x": 4}, 0.25},
+		{"x != 0 ? 1 / x : 1 / y", map[string]float64{"x": 2}, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateWith(tt.expression, tt.vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionalErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		wantErr    string
+	}{
+		{"1 ? 2", "missing : after ?"},
+		{"1 : 2", "unexpected : without ?"},
+		{"(1 ? 2) : 3", "missing : after ?"},
+		{"1 ? 1 / 0 : 2", "division by zero"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConditionalAudit(t *testing.T) {
+	var audited []string
+	e := NewEvaluator(Options{AuditHook: func(op string, operands []float64, result float64) {
+		audited = append(audited, op)
+	}})
+	if _, err := e.EvaluateExpression("2 > 1 ? 3 * 4 : 5 - 6"); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(audited, " "); got != "> *" {
+		t.Errorf("audited %q, want %q", got, "> *")
+	}
+}
+
+func TestUnescapeEntities(t *testing.T) {
+	tests := []struct {
+		expression string
+		tokens     string
+		want       float64
+	}{
+		{"5 &lt; 10", "5 < 10", 1},
+		{"10 &gt;= 5", "10 >= 5", 1},
+		{"6 &amp; 3", "6 & 3", 2},
+		{"1 &lt;&lt; 3", "1 << 3", 8},
+		{"2 &times; 3", "2 * 3", 6},
+		{"8 &divide; 2", "8 / 2", 4},
+		{"5 &minus; 2", "5 - 2", 3},
+		{"5&nbsp;+&#32;2", "5 + 2", 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e := NewEvaluator(Options{UnescapeEntities: true})
+			tokens, err := e.Tokens(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			values := make([]string, len(tokens))
+			for i, token := range tokens {
+				values[i] = token.Value
+			}
+			if got := strings.Join(values, " "); got != tt.tokens {
+				t.Errorf("tokens %q, want %q", got, tt.tokens)
+			}
+			got, err := e.EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewEvaluator(Options{}).EvaluateExpression("5 &lt; 10"); err == nil {
+		t.Error("entities are decoded without UnescapeEntities")
+	}
+}
+
+func TestPercentSign(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"50%", 0.5},
+		{"200 * 10%", 20},
+		{"200 - 10%", 199.9},
+		{"50% - 3", -2.5},
+		{"(50)%", 0.5},
+		{"7 % 3", 1},
+		// a sign directly before an operand keeps the remainder
+		{"7 % -3", 1},
+		{"7 %-3", 1},
+		{"-7 % +3", -1},
+		{"7 % -(1 + 2)", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInternalOperatorNames(t *testing.T) {
+	for _, expression := range []string{"50 percent", "2 plusminus 3", "neg 1", "neg(1)", "PERCENT(5)"} {
+		t.Run(expression, func(t *testing.T) {
+			e := NewEvaluator(Options{CaseInsensitiveFunctions: true})
+			got, err := e.EvaluateExpression(expression)
+			// read as variables, not operators
+			if err == nil {
+				t.Errorf("got %v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestAngleMode(t *testing.T) {
+	tests := []struct {
+		mode       AngleMode
+		expression string
+		want       float64
+	}{
+		{Radians, "sin(pi / 2)", 1},
+		{Radians, "sin(90)", math.Sin(90)},
+		{Radians, "atan2(1, 1)", math.Pi / 4},
+		{Radians, "atan2(1, -1)", 3 * math.Pi / 4},
+		{Radians, "atan2(-1, 0)", -math.Pi / 2},
+		{Degrees, "sin(90)", 1},
+		{Degrees, "cos(180)", -1},
+		{Degrees, "sin(180)", 0},
+		{Degrees, "tan(45)", math.Tan(math.Pi / 4)},
+		{Degrees, "tan(180)", 0},
+		{Degrees, "tan(90)", math.Inf(1)},
+		{Degrees, "asin(1)", 90},
+		{Degrees, "atan2(1, 1)", 45},
+		{Gradians, "sin(100)", 1},
+		{Gradians, "acos(0)", 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{AngleMode: tt.mode}).EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !(math.Abs(got-tt.want) <= 1e-12 || got == tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+	runExpressionErrors(t, Options{}, "atan2(1)", "atan2(1, 2, 3)", "atan2()")
+}
+
+func TestTangentAsymptote(t *testing.T) {
+	tests := []struct {
+		mode       AngleMode
+		expression string
+	}{
+		{Degrees, "tan(90)"},
+		{Degrees, "tan(-90)"},
+		{Degrees, "tan(270)"},
+		{Degrees, "tan(450)"},
+		{Gradians, "tan(100)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e := NewEvaluator(Options{AngleMode: tt.mode, DomainErrors: true})
+			got, err := e.EvaluateExpression(tt.expression)
+			if err == nil || !strings.Contains(err.Error(), "tan: argument out of domain") {
+				t.Errorf("got %v, %v, want a domain error", got, err)
+			}
+		})
+	}
+}
+
+func TestTokenSpans(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       []Token
+	}{
+		{"1+sqrt(4)", []Token{
+			{Type: Number, Value: "1", Start: 0, End: 1},
+			{Type: Operator, Value: "+", Start: 1, End: 2},
+			{Type: Operator, Value: "sqrt", Start: 2, End: 6},
+			{Type: LeftParen, Value: "(", Start: 6, End: 7},
+			{Type: Number, Value: "4", Start: 7, End: 8},
+			{Type: RightParen, Value: ")", Start: 8, End: 9},
+		}},
+		{"2**-x!!", []Token{
+			{Type: Number, Value: "2", Start: 0, End: 1},
+			{Type: Operator, Value: "**", Start: 1, End: 3},
+			{Type: Operator, Value: "neg", Start: 3, End: 4},
+			{Type: Variable, Value: "x", Start: 4, End: 5},
+			{Type: Operator, Value: "!!", Start: 5, End: 7},
+		}},
+		// rune rather than byte offsets
+		{"x² + 10", []Token{
+			{Type: Variable, Value: "x", Start: 0, End: 1},
+			{Type: Operator, Value: "²", Start: 1, End: 2},
+			{Type: Operator, Value: "+", Start: 3, End: 4},
+			{Type: Number, Value: "10", Start: 5, End: 7},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).Tokens(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPowerAssociativity(t *testing.T) {
+	tests := []struct {
+		expression   string
+		noPrecedence bool
+		want         float64
+	}{
+		{"2^3^2", false, 512},
+		{"2 ** 3 ** 2", false, 512},
+		{"(2^3)^2", false, 64},
+		{"2^-1^2", false, 0.5},
+		{"-2^2", false, -4},
+		{"2^3^2", true, 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e := NewEvaluator(Options{NoPrecedence: tt.noPrecedence})
+			got, err := e.EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMultiplicativeChains guards against * / % being given different
+// precedences again, checking chains against Go's own left to right
+// evaluation
+func TestMultiplicativeChains(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"100 / 10 / 2", 100.0 / 10 / 2},
+		{"2 * 3 % 4", math.Mod(2*3, 4)},
+		{"8 % 3 * 2", math.Mod(8, 3) * 2},
+		{"8 / 4 * 2", 8.0 / 4 * 2},
+		{"8 * 4 / 2", 8.0 * 4 / 2},
+		{"7 % 4 % 2", math.Mod(math.Mod(7, 4), 2)},
+		{"20 % 6 / 2", math.Mod(20, 6) / 2},
+		{"20 / 6 % 2", math.Mod(20.0/6, 2)},
+		{"2 * 9 % 5 * 3 / 2", math.Mod(2*9, 5) * 3 / 2},
+		{"1 / 3 * 3", 1.0 / 3 * 3},
+		{"100 / 5 % 7 * 2 / 4", math.Mod(100.0/5, 7) * 2 / 4},
+		{"-9 % 4 * 2", math.Mod(-9, 4) * 2},
+		{"1 + 6 / 3 * 2 - 5 % 3", 1 + 6.0/3*2 - math.Mod(5, 3)},
+		{"2 ^ 3 * 4 % 5", math.Mod(math.Pow(2, 3)*4, 5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateInteger(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       int64
+		err        string
+	}{
+		{"2 ^ 62", 1 << 62, ""},
+		{"-2 ^ 63", math.MinInt64, ""},
+		{"7 * 6", 42, ""},
+		{"2 ^ 63", 0, "integer overflow"},
+		{"10 ^ 30", 0, "integer overflow"},
+		{"-10 ^ 30", 0, "integer overflow"},
+		{"10 ^ 400", 0, "integer overflow"},
+		{"1 / 2", 0, "not an integer"},
+		{"nan", 0, "not a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateInteger(tt.expression)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("got %v, %v, want error %q", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGradians(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"sin(100)", 1},
+		{"cos(200)", -1},
+		{"sin(400)", 0},
+		{"tan(50)", 1},
+		{"asin(1)", 100},
+		{"acos(-1)", 200},
+		{"atan(1)", 50},
+		{"atan2(1, 0)", 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{AngleMode: Gradians}).EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetNumberParser(t *testing.T) {
+	dollars := func(s string) (float64, error) {
+		return strconv.ParseFloat(strings.TrimPrefix(s, "$"), 64)
+	}
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"$5 + $2.5", 7.5},
+		{"$10 * 3", 30},
+		{"4 / $2", 2},
+		{"2*$5", 10},
+		{"-$3", -3},
+		{"($1 + 1)", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e := NewEvaluator(Options{})
+			e.SetNumberParser(dollars)
+			got, err := e.EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetNumberParserTokens(t *testing.T) {
+	e := NewEvaluator(Options{})
+	if _, err := e.EvaluateExpression("$5"); err == nil {
+		t.Error("the default parser accepted $5")
+	}
+	e.SetNumberParser(func(s string) (float64, error) {
+		return strconv.ParseFloat(strings.TrimPrefix(s, "$"), 64)
+	})
+	tokens, err := e.Tokens("2*$5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Token{
+		{Type: Number, Value: "2", Start: 0, End: 1},
+		{Type: Operator, Value: "*", Start: 1, End: 2},
+		{Type: Number, Value: "$5", Start: 2, End: 4},
+	}
+	if !slices.Equal(tokens, want) {
+		t.Errorf("got %v, want %v", tokens, want)
+	}
+}
+
+func TestEvaluateRestricted(t *testing.T) {
+	disabled := []string{"^", "!"}
+	tests := []struct {
+		expression string
+		want       float64
+		restricted bool
+	}{
+		{"2 ^ 3", 8, true},
+		{"3!", 6, true},
+		{"2 * (1 + 3!)", 14, true},
+		{"2 * 3", 6, false},
+		{"sqrt(16)", 4, false},
+	}
+	e := NewEvaluator(Options{})
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := e.EvaluateRestricted(tt.expression, disabled)
+			if tt.restricted {
+				if err == nil {
+					t.Errorf("restricted call got %v, want an error", got)
+				}
+			} else if err != nil || got != tt.want {
+				t.Errorf("restricted call got %v, %v, want %v", got, err, tt.want)
+			}
+
+			// the symbols stay enabled for other calls
+			got, err = e.EvaluateExpression(tt.expression)
+			if err != nil || got != tt.want {
+				t.Errorf("got %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateRestrictedBypasses(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       Options
+		expression string
+		disabled   []string
+		want       float64
+		restricted bool
+	}{
+		{"function body", Options{}, "cube(2)", []string{"^"}, 8, true},
+		{"nested function body", Options{}, "twice(4)", []string{"sqrt"}, 4, true},
+		{"user function", Options{}, "cube(2)", []string{"cube"}, 8, true},
+		{"unary minus", Options{}, "-5", []string{"-"}, -5, true},
+		{"unary minus in a body", Options{}, "opposite(5)", []string{"-"}, -5, true},
+		{"binary minus only", Options{}, "-5", []string{"+"}, -5, false},
+		{"caret xor", Options{CaretXor: true}, "6 ^ 3", []string{"xor"}, 5, true},
+		{"caret xor is no power", Options{CaretXor: true}, "6 ^ 3", []string{"^"}, 5, false},
+		{"branch not taken", Options{}, "1 ? 2 : 2 ^ 3", []string{"^"}, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEvaluator(tt.opts)
+			err := e.DefineFunctions(map[string]string{
+				"cube":        "x ^ 3",
+				"root":        "sqrt(x)",
+				"twice(x)":    "2 * root(x)",
+				"opposite(x)": "-x",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := e.EvaluateRestricted(tt.expression, tt.disabled)
+			if tt.restricted {
+				if err == nil || !strings.Contains(err.Error(), "disabled") {
+					t.Errorf("got %v, %v, want a disabled error", got, err)
+				}
+			} else if err != nil || got != tt.want {
+				t.Errorf("got %v, %v, want %v", got, err, tt.want)
+			}
+
+			got, err = e.EvaluateExpression(tt.expression)
+			if err != nil || got != tt.want {
+				t.Errorf("unrestricted got %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		expression string
+		warnings   []string
+	}{
+		{"log(-1)", []string{"log produced NaN"}},
+		{"sqrt(-1) + 1", []string{"sqrt produced NaN"}},
+		{"9007199254740993", []string{"precision loss in number 9007199254740993"}},
+		{"1 + 1", nil},
+	}
+	e := NewEvaluator(Options{})
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			if _, err := e.EvaluateExpression(tt.expression); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(e.Warnings(), tt.warnings) {
+				t.Errorf("got %q, want %q", e.Warnings(), tt.warnings)
+			}
+		})
+	}
+}
+
+func TestWarningsClearedOnError(t *testing.T) {
+	e := NewEvaluator(Options{})
+	tests := map[string]func() error{
+		"EvaluateExpression": func() error { _, err := e.EvaluateExpression("pi(2)"); return err },
+		"EvaluateExpressionContext": func() error {
+			_, err := e.EvaluateExpressionContext(context.Background(), "1 +")
+			return err
+		},
+		"EvaluateTokens":     func() error { _, err := e.EvaluateTokens(nil); return err },
+		"EvaluateRestricted": func() error { _, err := e.EvaluateRestricted("(1", nil); return err },
+		"EvaluateGroups":     func() error { _, _, err := e.EvaluateGroups("1 +"); return err },
+		"EvaluateAll":        func() error { _, err := e.EvaluateAll("1 ±"); return err },
+		"EvaluateScript":     func() error { _, err := e.EvaluateScript("f(x) = x +"); return err },
+		"EvaluateRational":   func() error { _, err := e.EvaluateRational("1 +"); return err },
+		"Simplify":           func() error { _, err := e.Simplify("x +", nil); return err },
+	}
+	for name, evaluate := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := e.EvaluateExpression("log(-1)"); err != nil {
+				t.Fatal(err)
+			}
+			if len(e.Warnings()) == 0 {
+				t.Fatal("log(-1) got no warning")
+			}
+			if err := evaluate(); err == nil {
+				t.Fatal("got no error")
+			}
+			if warnings := e.Warnings(); len(warnings) != 0 {
+				t.Errorf("got %q after an error, want none", warnings)
+			}
+		})
+	}
+}
+
+func TestWarningsStrict(t *testing.T) {
+	e := NewEvaluator(Options{NaNPolicy: NaNError})
+	if _, err := e.EvaluateExpression("log(-1)"); err == nil {
+		t.Error("log(-1) did not fail with NaNError")
+	}
+}
+
+func TestEstimateCostBreakdown(t *testing.T) {
+	tests := []struct {
+		expression string
+		cost       int
+		breakdown  map[string]int
+	}{
+		{"1 + 2 * 3 + sqrt(4) ^ 2", 5, map[string]int{"+": 2, "*": 1, "sqrt": 1, "^": 1}},
+		{"max(1, 2, 3)! - -1", 4, map[string]int{"max": 1, "!": 1, "-": 1, "neg": 1}},
+		{"2(3)", 1, map[string]int{"*": 1}},
+		{"42", 0, map[string]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e := NewEvaluator(Options{})
+			breakdown, err := e.EstimateCostBreakdown(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(breakdown, tt.breakdown) {
+				t.Errorf("got %v, want %v", breakdown, tt.breakdown)
+			}
+			cost, err := e.EstimateCost(tt.expression)
+			if err != nil || cost != tt.cost {
+				t.Errorf("cost got %v, %v, want %v", cost, err, tt.cost)
+			}
+		})
+	}
+}
+
+func TestNoPrecedence(t *testing.T) {
+	tests := []struct {
+		expression string
+		// want is the result from left to right, precedence the default one
+		want, precedence float64
+	}{
+		{"2 + 3 * 4", 20, 14},
+		{"10 - 2 ^ 2", 64, 6},
+		{"2 ^ 3 ^ 2", 64, 512},
+		{"1 + sqrt(4) * 3", 9, 7},
+		{"2 * 3 + 4", 10, 10},
+		{"(2 + 3) * 4", 20, 20},
+		{"1 + 3!", 7, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{NoPrecedence: true}).EvaluateExpression(tt.expression)
+			if err != nil || got != tt.want {
+				t.Errorf("got %v, %v, want %v", got, err, tt.want)
+			}
+			got, err = NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			if err != nil || got != tt.precedence {
+				t.Errorf("with precedence got %v, %v, want %v", got, err, tt.precedence)
+			}
+		})
+	}
+}
+
+func TestInputBase(t *testing.T) {
+	tests := []struct {
+		base       int
+		expression string
+		want       float64
+	}{
+		{16, "ff + 1", 256},
+		{16, "FF", 255},
+		{16, "10", 16},
+		{16, "a * b", 110},
+		{16, "ff.8", 255.5},
+		// names that are all hex digits are numbers, others keep their meaning
+		{16, "e", 14},
+		{16, "abs(-a)", 10},
+		{16, "pi", math.Pi},
+		{2, "101 + 1", 6},
+		{2, "1010 * 10", 20},
+		{2, "0.1", 0.5},
+		{2, "e", math.E},
+		{8, "17 + 1", 16},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s in base %d", tt.expression, tt.base), func(t *testing.T) {
+			got, err := NewEvaluator(Options{InputBase: tt.base}).EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInputBaseInvalid(t *testing.T) {
+	tests := []struct {
+		base       int
+		expression string
+	}{
+		{2, "2"},
+		{2, "ff"},
+		{8, "9"},
+		{16, "x + 1"},
+		{37, "1"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s in base %d", tt.expression, tt.base), func(t *testing.T) {
+			got, err := NewEvaluator(Options{InputBase: tt.base}).EvaluateExpression(tt.expression)
+			if err == nil {
+				t.Errorf("got %v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestTrace(t *testing.T) {
+	var trace bytes.Buffer
+	e := NewEvaluator(Options{Trace: &trace})
+	if _, err := e.EvaluateExpression("1 + 2 * 3"); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(trace.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 4: %q", len(lines), trace.String())
+	}
+	if !strings.HasPrefix(lines[0], "tokens: ") || !strings.HasPrefix(lines[1], "reverse polish notation: ") {
+		t.Errorf("got %q, want the tokens and reverse polish notation", lines[:2])
+	}
+	if want := []string{"* [2 3] = 6", "+ [1 6] = 7"}; !slices.Equal(lines[2:], want) {
+		t.Errorf("got operations %q, want %q", lines[2:], want)
+	}
+
+	// without a writer nothing is traced, the evaluation works the same
+	e.Trace = nil
+	trace.Reset()
+	if got, err := e.EvaluateExpression("1 + 2 * 3"); err != nil || got != 7 {
+		t.Errorf("got %v, %v, want 7", got, err)
+	}
+	if trace.Len() != 0 {
+		t.Errorf("traced %q without a writer", trace.String())
+	}
+}
+
+func TestUnclosedCall(t *testing.T) {
+	tests := []struct {
+		expression string
+		err        string
+		pos        int
+	}{
+		{"sqrt(4 + 2", "unclosed call to sqrt", 0},
+		{"1 + max(1, abs(2)", "unclosed call to max", 4},
+		{"(1 + sqrt(4)", "mismatched parentheses", 0},
+		{"(1 + 2", "mismatched parentheses", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("got %v, want a syntax error", err)
+			}
+			if syntaxErr.Msg != tt.err || syntaxErr.Pos != tt.pos {
+				t.Errorf("got %q at %d, want %q at %d", syntaxErr.Msg, syntaxErr.Pos, tt.err, tt.pos)
+			}
+		})
+	}
+}
+
+// expiringContext is a context whose deadline passes once its Err has been
+// checked the remaining number of times, to cancel at a chosen check
+type expiringContext struct {
+	context.Context
+	remaining int
+}
+
+func (c *expiringContext) Err() error {
+	if c.remaining > 0 {
+		c.remaining--
+		return nil
+	}
+	return context.DeadlineExceeded
+}
+
+func TestEvaluateExpressionContext(t *testing.T) {
+	// 2^53 - 111, the largest prime a float64 holds exactly
+	const largePrime = "9007199254740881"
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		expression string
+		want       float64
+		wantErr    error
+	}{
+		{"background", context.Background(), "isprime(97) + comb(5, 2)", 11, nil},
+		{"canceled before evaluation", canceled, "1 + 2", 0, context.Canceled},
+		// the check before applying isprime passes, the next one is
+		// within its loop
+		{"deadline within isprime", &expiringContext{Context: context.Background(), remaining: 1},
+			"1 + isprime(" + largePrime + ")", 0, context.DeadlineExceeded},
+		// without a loop long enough to check the context
+		{"deadline after isprime", &expiringContext{Context: context.Background(), remaining: 1},
+			"isprime(97)", 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateExpressionContext(tt.ctx, tt.expression)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateExpressionContextRecursion(t *testing.T) {
+	// f30 calls f0 2^30 times
+	definitions := []string{"f0(x) = x + 1"}
+	for i := 1; i <= 30; i++ {
+		definitions = append(definitions, fmt.Sprintf("f%d(x) = f%d(x) + f%d(x)", i, i-1, i-1))
+	}
+	e := NewEvaluator(Options{})
+	if _, err := e.EvaluateScript(strings.Join(definitions, "; ") + "; 0"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := e.EvaluateExpressionContext(ctx, "f30(1)")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestEvaluateExpressionContextConcurrent(t *testing.T) {
+	e := NewEvaluator(Options{})
+	if _, err := e.EvaluateScript("f(x) = x * 2; 0"); err != nil {
+		t.Fatal(err)
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if i%2 == 0 {
+					if _, err := e.EvaluateExpressionContext(canceled, "f(1)"); !errors.Is(err, context.Canceled) {
+						t.Errorf("error = %v, want %v", err, context.Canceled)
+					}
+					continue
+				}
+				got, err := e.EvaluateExpressionContext(context.Background(), "f(f(1))")
+				if err != nil || got != 4 {
+					t.Errorf("got %v, %v, want 4", got, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestEvaluateTokens(t *testing.T) {
+	// 2 * (3 + 4), without positions as a program would build it
+	tokens := []Token{
+		{Type: Number, Value: "2"},
+		{Type: Operator, Value: "*"},
+		{Type: LeftParen, Value: "("},
+		{Type: Number, Value: "3"},
+		{Type: Operator, Value: "+"},
+		{Type: Number, Value: "4"},
+		{Type: RightParen, Value: ")"},
+	}
+	got, err := NewEvaluator(Options{}).EvaluateTokens(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 14 {
+		t.Errorf("got %v, want 14", got)
+	}
+
+	// the tokens of an expression evaluate the same
+	e := NewEvaluator(Options{})
+	lexed, err := e.Tokens("sqrt(16) - -1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := e.EvaluateTokens(lexed); err != nil || got != 5 {
+		t.Errorf("got %v, %v, want 5", got, err)
+	}
+}
+
+func TestEvaluateTokensInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []Token
+	}{
+		{"empty", nil},
+		{"two numbers", []Token{{Type: Number, Value: "1"}, {Type: Number, Value: "2"}}},
+		{"trailing operator", []Token{{Type: Number, Value: "1"}, {Type: Operator, Value: "+"}}},
+		{"unknown operator", []Token{{Type: Number, Value: "1"}, {Type: Operator, Value: "@"}, {Type: Number, Value: "2"}}},
+		{"unclosed", []Token{{Type: LeftParen, Value: "("}, {Type: Number, Value: "1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateTokens(tt.tokens)
+			if err == nil {
+				t.Errorf("got %v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestUnarySigns(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"-5", -5},
+		{"-5 + 3", -2},
+		{"+5", 5},
+		{"3 * -2", -6},
+		{"(-4)^2", 16},
+		{"2 - -3", 5},
+		{"2 + +3", 5},
+		{"--5", 5},
+		{"-(1 + 2)", -3},
+		{"max(-1, -2)", -1},
+		{"-sqrt(4)", -2},
+		{"-2^2", -4},
+		{"2^-1", 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeadingZeros(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"007 + 1", 8},
+		{"010", 10},
+		{"0010.5", 10.5},
+		{"0", 0},
+		{"0o10", 8},
+		{"0O17 + 1", 16},
+		{"0x10", 16},
+		{"0b10", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// octal mode reads them as octal
+	got, err := NewEvaluator(Options{InputBase: 8}).EvaluateExpression("010")
+	if err != nil || got != 8 {
+		t.Errorf("010 in base 8 got %v, %v, want 8", got, err)
+	}
+	if _, err := NewEvaluator(Options{}).EvaluateExpression("0o8"); err == nil {
+		t.Error("0o8 is not a valid octal literal")
+	}
+}
+
+func TestDomainErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		err        string
+	}{
+		{"asin(2)", "asin: argument out of domain [-1,1]: 2"},
+		{"acos(-3)", "acos: argument out of domain [-1,1]: -3"},
+		{"log(-1)", "log: argument out of domain (0,+inf): -1"},
+		{"log(0)", "log: argument out of domain (0,+inf): 0"},
+		{"1 + log10(-1)", "log10: argument out of domain (0,+inf): -1"},
+		{"log2(-2)", "log2: argument out of domain (0,+inf): -2"},
+		{"sqrt(-4)", "sqrt: argument out of domain [0,+inf): -4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := NewEvaluator(Options{DomainErrors: true}).EvaluateExpression(tt.expression)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+
+	got, err := NewEvaluator(Options{DomainErrors: true}).EvaluateExpression("asin(1) + sqrt(0)")
+	if err != nil || got != math.Pi/2 {
+		t.Errorf("arguments in domain got %v, %v, want pi/2", got, err)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	got, err := NewEvaluator(Options{}).Tokens("sqrt(16) + 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Token{
+		{Type: Operator, Value: "sqrt", Start: 0, End: 4},
+		{Type: LeftParen, Value: "(", Start: 4, End: 5},
+		{Type: Number, Value: "16", Start: 5, End: 7},
+		{Type: RightParen, Value: ")", Start: 7, End: 8},
+		{Type: Operator, Value: "+", Start: 9, End: 10},
+		{Type: Number, Value: "2", Start: 11, End: 12},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestTokensMapBack checks that the offsets of every token select the
+// text it was read from, the sign typed for neg and percent
+func TestTokensMapBack(t *testing.T) {
+	for _, expression := range []string{
+		"sqrt(16) + 2",
+		"max(1,\t2.5) * 3!!",
+		"  0xff << 2 >= 1e3 ? x : y",
+		"3² - -x + 50%",
+		"[1 + 2] ** 3 % 4",
+	} {
+		t.Run(expression, func(t *testing.T) {
+			tokens, err := NewEvaluator(Options{}).Tokens(expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			runes := []rune(expression)
+			for _, token := range tokens {
+				if token.Start < 0 || token.End > len(runes) || token.Start >= token.End {
+					t.Fatalf("token %v is out of range", token)
+				}
+				value := token.Value
+				if sign, ok := internalOperators[value]; ok {
+					value = sign
+				}
+				if typed := string(runes[token.Start:token.End]); typed != value {
+					t.Errorf("token %v selects %q", token, typed)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxMagnitude(t *testing.T) {
+	tests := []struct {
+		limit      float64
+		expression string
+		want       float64
+		err        bool
+	}{
+		{1e15, "10^20", 0, true},
+		{1e15, "-10^20", 0, true},
+		{1e15, "10^15", 1e15, false},
+		// only the final result is checked
+		{1e15, "10^20 / 10^10", 1e10, false},
+		{0, "10^20", 1e20, false},
+		{1e15, "nan", math.NaN(), false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s with %v", tt.expression, tt.limit), func(t *testing.T) {
+			got, err := NewEvaluator(Options{MaxMagnitude: tt.limit}).EvaluateExpression(tt.expression)
+			if tt.err {
+				if err == nil || !strings.Contains(err.Error(), "overflow") {
+					t.Errorf("got %v, %v, want an overflow error", got, err)
+				}
+				return
+			}
+			if err != nil || !(got == tt.want || math.IsNaN(got) && math.IsNaN(tt.want)) {
+				t.Errorf("got %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateWritesNothing(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	e := NewEvaluator(Options{})
+	for _, expression := range []string{"1 + 2 * 3", "max(1, 2) + sqrt(4)", "1 +"} {
+		_, _ = e.EvaluateExpression(expression)
+	}
+	_, _ = e.EvaluateScript("f(x) = x; f(2)")
+	_, _ = e.Simplify("2 + 3 * x", nil)
+
+	os.Stdout = stdout
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	written, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 0 {
+		t.Errorf("wrote %q to stdout", written)
+	}
+}
+
+func TestRoundOperation(t *testing.T) {
+	twoDecimals := func(v float64) float64 { return RoundDecimals(v, 2) }
+	sevenDigits := func(v float64) float64 { return RoundSignificant(v, 7) }
+	chain := strings.Repeat("1/3 + ", 29) + "1/3"
+	tests := []struct {
+		name       string
+		opts       Options
+		expression string
+		want       float64
+	}{
+		// each third is 0.33, so their sum falls short of 10
+		{"per operation", Options{RoundOperation: twoDecimals}, chain, 9.9},
+		{"final only", Options{Precision: 2}, chain, 10},
+		{"per operation digits", Options{RoundOperation: sevenDigits}, "1/3 * 10^7", 3333333},
+		{"exact digits", Options{}, "1/3 * 10^7", 1e7 / 3},
+		{"compounded", Options{RoundOperation: math.Round}, "1.4 * 1.4 * 1.4", 3},
+		{"compounded final only", Options{Precision: 0}, "1.4 * 1.4 * 1.4", 1.4 * 1.4 * 1.4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewEvaluator(tt.opts).EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 1e-12*math.Max(1, math.Abs(tt.want)) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommaArguments(t *testing.T) {
+	tests := []struct {
+		expression string
+		rpn        []string
+		args       int
+	}{
+		{"max(1, 2, 3)", []string{"1", "2", "3", "max"}, 3},
+		{"max(1 + 2, 3 * 4)", []string{"1", "2", "+", "3", "4", "*", "max"}, 2},
+		{"atan2(1, max(2, 3))", []string{"1", "2", "3", "max", "atan2"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			rpn, err := NewEvaluator(Options{}).ToRPN(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			values := make([]string, len(rpn))
+			for i, token := range rpn {
+				values[i] = token.Value
+			}
+			if !slices.Equal(values, tt.rpn) {
+				t.Errorf("got %v, want %v", values, tt.rpn)
+			}
+			if last := rpn[len(rpn)-1]; last.args != tt.args {
+				t.Errorf("%s got %d arguments, want %d", last.Value, last.args, tt.args)
+			}
+		})
+	}
+}
+
+func TestStrayComma(t *testing.T) {
+	for _, expression := range []string{"1, 2", "(1, 2)", "max(1,)", "max(, 1)", ",", "2 * (3, 4)"} {
+		t.Run(expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateExpression(expression)
+			if err == nil {
+				t.Errorf("got %v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestStrictParentheses(t *testing.T) {
+	strict := NewEvaluator(Options{StrictParentheses: true})
+	for _, expression := range []string{"sqrt 4", "2 * sqrt 16", "abs -3"} {
+		if got, err := strict.EvaluateExpression(expression); err == nil || !strings.Contains(err.Error(), "must be called with parentheses") {
+			t.Errorf("%s: got %v, %v, want a parentheses error", expression, got, err)
+		}
+	}
+	for expression, want := range map[string]float64{"sqrt(4)": 2, "2 * sqrt(16)": 8, "abs(-3) + 3!": 9} {
+		if got, err := strict.EvaluateExpression(expression); err != nil || got != want {
+			t.Errorf("%s: got %v, %v, want %v", expression, got, err, want)
+		}
+	}
+	if got, err := NewEvaluator(Options{}).EvaluateExpression("sqrt 4"); err != nil || got != 2 {
+		t.Errorf("sqrt 4 without the option got %v, %v, want 2", got, err)
+	}
+}
+
+func TestFortranExponent(t *testing.T) {
+	fortran := NewEvaluator(Options{FortranExponent: true})
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"1.5D3", 1500},
+		{"1.5d3", 1500},
+		{"2D-2", 0.02},
+		{"1e3 + 1D3", 2000},
+		// d without a digit after it is still a name
+		{"2d", 2 * 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := fortran.EvaluateWith(tt.expression, map[string]float64{"d": 4})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// without the flag d is a variable, 1.5d3 is not a number
+	got, err := NewEvaluator(Options{}).EvaluateWith("2d", map[string]float64{"d": 4})
+	if err != nil || got != 8 {
+		t.Errorf("2d without the flag got %v, %v, want 8", got, err)
+	}
+	if _, err := NewEvaluator(Options{}).EvaluateExpression("1.5D3"); err == nil {
+		t.Error("1.5D3 evaluated without the flag")
+	}
+}
+
+func TestTrailingOperators(t *testing.T) {
+	tests := []struct {
+		expression string
+		err        string
+		pos        int
+	}{
+		// trailing functions
+		{"3 + sin", "function sin missing argument", 4},
+		{"sin", "function sin missing argument", 0},
+		{"2 * sqrt", "function sqrt missing argument", 4},
+		// trailing infix operators
+		{"3 +", "expression cannot end with an operator", 2},
+		{"3 *", "expression cannot end with an operator", 2},
+		{"3 + -", "expression cannot end with an operator", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("got %v, want a syntax error", err)
+			}
+			if syntaxErr.Msg != tt.err || syntaxErr.Pos != tt.pos {
+				t.Errorf("got %q at %d, want %q at %d", syntaxErr.Msg, syntaxErr.Pos, tt.err, tt.pos)
+			}
+		})
+	}
+}
+
+func TestNaNPolicy(t *testing.T) {
+	tests := []struct {
+		expression string
+		// propagated is true if NaNPropagate gives NaN, NaNError must fail
+		// exactly then
+		propagated bool
+	}{
+		{"nan + 1", true},
+		{"nan * 0", true},
+		{"sqrt(-1)", true},
+		{"log(-1) + 1", true},
+		{"1 + 1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			if err != nil || math.IsNaN(got) != tt.propagated {
+				t.Errorf("propagate got %v, %v", got, err)
+			}
+			got, err = NewEvaluator(Options{NaNPolicy: NaNError}).EvaluateExpression(tt.expression)
+			if (err != nil) != tt.propagated {
+				t.Errorf("error got %v, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestEvaluateWith(t *testing.T) {
+	vars := map[string]float64{"x": 3, "y": 4, "x_1": 10, "rate2": 0.5}
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"x*2+y", 10},
+		{"x_1 * rate2", 5},
+		{"sqrt(x*x + y*y)", 5},
+		{"2x", 6},
+		{"x + pi", 3 + math.Pi},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateWith(tt.expression, vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	_, err := NewEvaluator(Options{}).EvaluateWith("x + z", vars)
+	if err == nil || err.Error() != "undefined variable: z" {
+		t.Errorf("got error %v, want undefined variable: z", err)
+	}
+}
+
+func TestToRPN(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{"3 + 4 * 2", "3 4 2 * +"},
+		{"(3 + 4) * 2", "3 4 + 2 *"},
+		{"2 ^ 3 ^ 2", "2 3 2 ^ ^"},
+		{"10 - 4 - 3", "10 4 - 3 -"},
+		{"-3 + 4", "3 neg 4 +"},
+		{"sqrt(16) + 1", "16 sqrt 1 +"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			rpn, err := NewEvaluator(Options{}).ToRPN(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			values := make([]string, len(rpn))
+			for i, token := range rpn {
+				values[i] = token.Value
+			}
+			if got := strings.Join(values, " "); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if _, err := NewEvaluator(Options{}).ToRPN("3 + (4"); err == nil {
+		t.Error("got no error for an unbalanced parenthesis")
+	}
+}
+
+func TestImplicitMultiplication(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"2(3+4)", 14},
+		{"(1+2)(3+4)", 21},
+		{"(1+2)3", 9},
+		{"2(3)(4)", 24},
+		{"2pi", 2 * math.Pi},
+		{"2sqrt(4)", 4},
+		{"1 + 2(3)", 7},
+	})
+	runExpressionErrors(t, Options{}, "2 3", "x(2)", "pi(2)")
+}
+
+func TestRadixPoint(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"0x1.8", 1.5},
+		{"0b1.1", 1.5},
+		{"0b10.1", 2.5},
+		{"0o7.4", 7.5},
+		{"0x1.8 * 2", 3},
+	})
+	runExpressionErrors(t, Options{}, "0x1.8.1", "0x1.G", "0b1.2")
+}
+
+func TestHexBinaryLiterals(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"0xFF", 255},
+		{"0xff", 255},
+		{"0XFF", 255},
+		{"0b1010", 10},
+		{"0B1010", 10},
+		{"0xFF + 1", 256},
+		{"0b11 * 2", 6},
+		{"-0x10", -16},
+	})
+	runExpressionErrors(t, Options{}, "0xG1", "0x1G", "0b12", "0b102")
+}
+
+func TestSetAuditHook(t *testing.T) {
+	type entry struct {
+		op       string
+		operands []float64
+		result   float64
+	}
+	var audited []entry
+	e := NewEvaluator(Options{})
+	e.SetAuditHook(func(op string, operands []float64, result float64) {
+		audited = append(audited, entry{op, operands, result})
+	})
+	if _, err := e.EvaluateScript("sq(x) = x * x; -sq(3) + max(1, 2, 5)"); err != nil {
+		t.Fatal(err)
+	}
+	want := []entry{
+		{"*", []float64{3, 3}, 9},
+		{"sq", []float64{3}, 9},
+		{"neg", []float64{9}, -9},
+		{"max", []float64{1, 2, 5}, 5},
+		{"+", []float64{-9, 5}, -4},
+	}
+	if len(audited) != len(want) {
+		t.Fatalf("audited %v, want %v", audited, want)
+	}
+	for i, got := range audited {
+		if got.op != want[i].op || !slices.Equal(got.operands, want[i].operands) || got.result != want[i].result {
+			t.Errorf("entry %d got %v, want %v", i, got, want[i])
+		}
+	}
+
+	audited = nil
+	e.SetAuditHook(nil)
+	if _, err := e.EvaluateExpression("1 + 2"); err != nil {
+		t.Fatal(err)
+	}
+	if len(audited) != 0 {
+		t.Errorf("removed hook audited %v", audited)
+	}
+}
+
+func TestRunLeavesNoSingleValue(t *testing.T) {
+	// reverse polish notation not checked by validate, as converting
+	// expressions never produces it
+	tests := []struct {
+		name           string
+		polishNotation []Token
+	}{
+		{"empty", nil},
+		{"two values", []Token{{Type: Number, Value: "1"}, {Type: Number, Value: "2"}}},
+	}
+	e := NewEvaluator(Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression := &CompiledExpression{evaluator: e, polishNotation: tt.polishNotation}
+			expression.operators = e.resolve(expression.polishNotation)
+			got, err := expression.Evaluate(nil)
+			if err == nil {
+				t.Errorf("got %v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestSinglePrimary(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"42", 42},
+		{"  42  ", 42},
+		{"\t42\n", 42},
+		{"-42", -42},
+		{" -42 ", -42},
+		{"+42", 42},
+		{"3.5", 3.5},
+		{"pi", math.Pi},
+		{" e ", math.E},
+		{"-pi", -math.Pi},
+		{"(42)", 42},
+		{"((-42))", -42},
+	})
+	runExpressionErrors(t, Options{}, "", "  ", "\t\n")
+}
+
+func TestConstants(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"2 * pi", 2 * math.Pi},
+		{"e ^ 2", math.E * math.E},
+		{"pi / 2 + e", math.Pi/2 + math.E},
+		{"sin(pi / 2)", 1},
+		{"log(e)", 1},
+	})
+
+	tests := []struct {
+		expression string
+		wantErr    string
+	}{
+		{"pi(2)", "pi is not a function at position 0"},
+		{"2 * e(1)", "e is not a function at position 4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEmptyParentheses(t *testing.T) {
+	tests := []struct {
+		expression string
+		wantErr    string
+	}{
+		{"()", "empty parentheses at position 0"},
+		{"( )", "empty parentheses at position 0"},
+		{"3 * ()", "empty parentheses at position 4"},
+		{"(())", "empty parentheses at position 1"},
+		{"2()", "empty parentheses at position 1"},
+		{"sqrt()", "function sqrt expects 1 argument(s), got 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrecision(t *testing.T) {
+	tests := []struct {
+		precision  int
+		expression string
+		want       float64
+	}{
+		{2, "0.1 + 0.2", 0.3},
+		{2, "2 / 3", 0.67},
+		{2, "-2 / 3", -0.67},
+		{1, "1234.5678", 1234.6},
+		{3, "1234.5678", 1234.568},
+		// full precision by default
+		{0, "0.1 + 0.2", 0.30000000000000004},
+		{0, "2 / 3", 0.6666666666666666},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s to %d", tt.expression, tt.precision), func(t *testing.T) {
+			got, err := NewEvaluator(Options{Precision: tt.precision}).EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want exactly %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSquareBrackets(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"[1 + 2] * 3", 9},
+		{"[(1 + 2) * [3 - 1]]", 6},
+		{"([1 + 2] * 2)", 6},
+		{"sqrt[16]", 4},
+		{"2[3]", 6},
+	})
+
+	tests := []struct {
+		expression string
+		wantErr    string
+	}{
+		{"[1 + 2)", "mismatched ) closing [ at position 6"},
+		{"(1 + 2]", "mismatched ] closing ( at position 6"},
+		{"[(1 + 2])", "mismatched ] closing ( at position 7"},
+		{"[1 + 2", "mismatched parentheses at position 0"},
+		{"1 + 2]", "mismatched parentheses at position 5"},
+		{"[]", "empty parentheses at position 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMaxNesting(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       int
+	}{
+		{"1 + 2", 0},
+		{"x * y", 0},
+		{"(1 + 2) * (3 + 4)", 1},
+		{"(1 + sqrt(4))", 2},
+		{"[(1 + 2) * 3]", 2},
+		{strings.Repeat("(", 50) + "1" + strings.Repeat(")", 50), 50},
+		{"((1) + ((2 + (3))))", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).MaxNesting(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+	for _, expression := range []string{"((1)", "1)", "1 +"} {
+		if _, err := NewEvaluator(Options{}).MaxNesting(expression); err == nil {
+			t.Errorf("%s got no error", expression)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// CompiledExpression is an expression tokenized and converted to reverse
+// polish notation once, so it can be evaluated many times with different
+// variable values.
+type CompiledExpression struct {
+	evaluator      *Evaluator
+	polishNotation []Token
+	// operators are resolved once when compiling, so evaluating does not
+	// need to look them up in the factory
+	operators []OperatorEvaluator
+
+	// cache is the opt-in cache of results, see EnableCache
+	cache *resultCache
+	// variables are the names used by the expression, keying the cache
+	variables []string
+	// constants are the names the user defined functions called read as
+	// constants, also keying the cache
+	constants []string
+}
+
+// Expression is another name of CompiledExpression
+type Expression = CompiledExpression
+
+// Compile parses the expression for repeated evaluation. Names that are not
+// operators or functions are variables, given on each evaluation.
+func (e *Evaluator) Compile(expression string) (*CompiledExpression, error) {
+	tokens, err := e.tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	polishNotation, err := e.toReversePolishNotation(tokens)
+	if err != nil {
+		return nil, err
+	}
+	return &CompiledExpression{
+		evaluator:      e,
+		polishNotation: polishNotation,
+		operators:      e.resolve(polishNotation),
+	}, nil
+}
+
+// Evaluate evaluates the compiled expression with the given variable values.
+// Returns an error if a variable used by the expression is missing.
+func (c *CompiledExpression) Evaluate(vars map[string]float64) (float64, error) {
+	ev := &evaluation{ctx: context.Background()}
+	if c.cache == nil {
+		return c.evaluator.runExpression(ev, c.polishNotation, c.operators, vars)
+	}
+	key := c.cacheKey(vars)
+	if entry, ok := c.cache.get(key); ok {
+		c.evaluator.setWarnings(slices.Clone(entry.warnings))
+		return entry.result, nil
+	}
+	result, err := c.evaluator.runExpression(ev, c.polishNotation, c.operators, vars)
+	if err != nil {
+		return 0, err
+	}
+	c.cache.put(&cacheEntry{
+		key:      key,
+		result:   result,
+		warnings: slices.Clone(ev.warnings),
+	})
+	return result, nil
+}
+
+// Eval is the same as Evaluate
+func (c *CompiledExpression) Eval(vars map[string]float64) (float64, error) {
+	return c.Evaluate(vars)
+}
+
+// maxSamples limits the number of points returned by Sample
+const maxSamples = 1 << 20
+
+// Sample evaluates the compiled expression for the variable varName from start
+// to end (inclusive) by step, returning the (x, y) pairs, e.g. for plotting.
+// Returns an error if the bounds or step are not finite or the range has
+// more than 1048576 points.
+func (c *CompiledExpression) Sample(varName string, start, end, step float64) ([][2]float64, error) {
+	for _, v := range []float64{start, end, step} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("start, end and step must be finite, got %v", v)
+		}
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("step must be positive")
+	}
+	if end < start {
+		return nil, nil
+	}
+	// may be +Inf for a huge range
+	count := math.Floor((end-start)/step) + 1
+	if count > maxSamples {
+		return nil, fmt.Errorf("sampling from %v to %v by %v exceeds %d points", start, end, step, maxSamples)
+	}
+	points := make([][2]float64, 0, int(count))
+	vars := map[string]float64{}
+	// multiply rather than accumulate the step to avoid drifting, the
+	// count may be one short by rounding
+	for i := 0; i <= int(count); i++ {
+		x := start + float64(i)*step
+		if x > end {
+			break
+		}
+		vars[varName] = x
+		y, err := c.Evaluate(vars)
+		if err != nil {
+			return nil, fmt.Errorf("evaluating at %s = %v: %w", varName, x, err)
+		}
+		points = append(points, [2]float64{x, y})
+	}
+	return points, nil
+}
@@ -0,0 +1,153 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	c, err := NewEvaluator(Options{}).Compile("x^2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name             string
+		start, end, step float64
+		want             [][2]float64
+	}{
+		{"integers", 0, 3, 1, [][2]float64{{0, 0}, {1, 1}, {2, 4}, {3, 9}}},
+		{"end between steps", 0, 2.5, 1, [][2]float64{{0, 0}, {1, 1}, {2, 4}}},
+		{"single point", 2, 2, 1, [][2]float64{{2, 4}}},
+		{"empty range", 3, 0, 1, nil},
+		{"fractional step", 0, 1, 0.25, [][2]float64{{0, 0}, {0.25, 0.0625}, {0.5, 0.25}, {0.75, 0.5625}, {1, 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Sample("x", tt.start, tt.end, tt.step)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSampleInvalid(t *testing.T) {
+	c, err := NewEvaluator(Options{}).Compile("x^2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name             string
+		start, end, step float64
+	}{
+		{"zero step", 0, 1, 0},
+		{"negative step", 0, 1, -1},
+		{"NaN step", 0, 1, math.NaN()},
+		{"NaN start", math.NaN(), 1, 1},
+		{"infinite end", 0, math.Inf(1), 1},
+		{"infinite start", math.Inf(-1), 0, 1},
+		{"too many points", 0, 1e7, 1},
+		{"huge range", -math.MaxFloat64, math.MaxFloat64, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Sample("x", tt.start, tt.end, tt.step)
+			if err == nil {
+				t.Errorf("got %d points, want an error", len(got))
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	e := NewEvaluator(Options{})
+	expression, err := e.Compile("a*a + b*b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct{ a, b, want float64 }{{3, 4, 25}, {1, 1, 2}, {0, 0, 0}} {
+		got, err := expression.Eval(map[string]float64{"a": tt.a, "b": tt.b})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if _, err := expression.Eval(map[string]float64{"a": 1}); err == nil {
+		t.Error("evaluated with b missing")
+	}
+	if _, err := e.Compile("a * (b"); err == nil {
+		t.Error("compiled unbalanced parentheses")
+	}
+}
+
+func BenchmarkCompileEval(b *testing.B) {
+	expression, err := NewEvaluator(Options{}).Compile("a*a + b*b")
+	if err != nil {
+		b.Fatal(err)
+	}
+	vars := map[string]float64{"a": 3, "b": 4}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := expression.Eval(vars); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEvaluateExpression(b *testing.B) {
+	e := NewEvaluator(Options{})
+	vars := map[string]float64{"a": 3, "b": 4}
+	for i := 0; i < b.N; i++ {
+		if _, err := e.EvaluateWith("a*a + b*b", vars); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCompileEvalUnresolved is BenchmarkCompileEval looking up each
+// operator in the factory on every evaluation, as before operators were
+// resolved when compiling
+func BenchmarkCompileEvalUnresolved(b *testing.B) {
+	expression, err := NewEvaluator(Options{}).Compile("a*a + b*b")
+	if err != nil {
+		b.Fatal(err)
+	}
+	expression.operators = nil
+	vars := map[string]float64{"a": 3, "b": 4}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := expression.Eval(vars); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
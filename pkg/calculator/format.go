@@ -0,0 +1,130 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ApproxFraction returns the simplest fraction num/den within tol of v,
+// found by expanding v into a continued fraction, e.g. 0.3333333 is 1/3.
+//
+// The denominator is always positive, and zero if v is NaN, infinite or
+// too large for int64.
+func ApproxFraction(v float64, tol float64) (num, den int64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, 0
+	}
+	sign := int64(1)
+	if v < 0 {
+		sign = -1
+		v = -v
+	}
+	// h/k are the convergents, starting from h(-2)/k(-2) = 0/1 and h(-1)/k(-1) = 1/0
+	h0, h1 := int64(0), int64(1)
+	k0, k1 := int64(1), int64(0)
+	x := v
+	for {
+		a := math.Floor(x)
+		if a >= math.MaxInt64 {
+			break
+		}
+		term := int64(a)
+		if term != 0 && (h1 > (math.MaxInt64-h0)/term || k1 > (math.MaxInt64-k0)/term) {
+			// the next convergent does not fit in int64, keep the last one
+			break
+		}
+		h0, h1 = h1, term*h1+h0
+		k0, k1 = k1, term*k1+k0
+		if math.Abs(v-float64(h1)/float64(k1)) <= tol || x == a {
+			break
+		}
+		x = 1 / (x - a)
+	}
+	return sign * h1, k1
+}
+
+// RoundSignificant rounds the value to the given number of significant digits,
+// e.g. 1234567 to 3 digits is 1230000 and 0.00123456 is 0.00123.
+//
+// The value is returned unchanged if digits is not positive.
+func RoundSignificant(value float64, digits int) float64 {
+	if digits <= 0 || value == 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+	// formatting in exponent notation rounds to exactly the digits wanted
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(value, 'e', digits-1, 64), 64)
+	if err != nil {
+		return value
+	}
+	return rounded
+}
+
+// RoundDecimals rounds the value to the given number of decimal places,
+// e.g. 0.30000000000000004 to 2 decimals is 0.3 and 1234.5678 is 1234.57.
+//
+// The value is returned unchanged if decimals is negative.
+func RoundDecimals(value float64, decimals int) float64 {
+	if decimals < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+	// formatting rounds the decimal digits, unlike scaling by a power of 10
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(value, 'f', decimals, 64), 64)
+	if err != nil {
+		return value
+	}
+	return rounded
+}
+
+// FormatGrouped formats the value like strconv.FormatFloat(value, 'f', -1, 64)
+// with the separator inserted every three digits of the integer part,
+// e.g. 1234567.891 is 1,234,567.891. The fractional part is not grouped.
+func FormatGrouped(value float64, separator rune) string {
+	formatted := strconv.FormatFloat(value, 'f', -1, 64)
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return formatted
+	}
+	sign, digits := "", formatted
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(digits, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, c := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteRune(separator)
+		}
+		b.WriteRune(c)
+	}
+	if hasFraction {
+		b.WriteByte('.')
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
@@ -0,0 +1,121 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestApproxFraction(t *testing.T) {
+	tests := []struct {
+		name     string
+		v, tol   float64
+		num, den int64
+	}{
+		{"1/3", 1.0 / 3, 1e-9, 1, 3},
+		{"0.3333333", 0.3333333, 1e-6, 1, 3},
+		{"0.5", 0.5, 1e-9, 1, 2},
+		{"-0.75", -0.75, 1e-9, -3, 4},
+		{"2", 2, 1e-9, 2, 1},
+		{"0", 0, 1e-9, 0, 1},
+		{"sqrt(2)", math.Sqrt2, 1e-6, 1393, 985},
+		{"sqrt(2) loosely", math.Sqrt2, 1e-2, 17, 12},
+		{"pi", math.Pi, 2e-3, 22, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			num, den := ApproxFraction(tt.v, tt.tol)
+			if num != tt.num || den != tt.den {
+				t.Errorf("got %d/%d, want %d/%d", num, den, tt.num, tt.den)
+			}
+		})
+	}
+}
+
+func TestApproxFractionUnrepresentable(t *testing.T) {
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), 1e300} {
+		if _, den := ApproxFraction(v, 1e-9); den != 0 {
+			t.Errorf("%v: got denominator %d, want 0", v, den)
+		}
+	}
+}
+
+func TestRoundSignificant(t *testing.T) {
+	tests := []struct {
+		value  float64
+		digits int
+		want   float64
+	}{
+		{1234567, 3, 1230000},
+		{1234567, 1, 1000000},
+		{9999, 2, 10000},
+		{0.00123456, 3, 0.00123},
+		{-0.00123456, 2, -0.0012},
+		{1.23456e-20, 4, 1.235e-20},
+		{6.02214076e23, 3, 6.02e23},
+		{2.51, 1, 3},
+		{1234567, 0, 1234567},
+		{0, 3, 0},
+		{math.Inf(1), 3, math.Inf(1)},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.FormatFloat(tt.value, 'g', -1, 64), func(t *testing.T) {
+			if got := RoundSignificant(tt.value, tt.digits); got != tt.want {
+				t.Errorf("%d digits got %v, want %v", tt.digits, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatGrouped(t *testing.T) {
+	tests := []struct {
+		value     float64
+		separator rune
+		want      string
+	}{
+		{0, ',', "0"},
+		{999, ',', "999"},
+		{1000, ',', "1,000"},
+		{1234567, ',', "1,234,567"},
+		{123456789012, ',', "123,456,789,012"},
+		{-1234567.891, ',', "-1,234,567.891"},
+		{0.123456, ',', "0.123456"},
+		{1234.5678, '.', "1.234.5678"},
+		{1234567, ' ', "1 234 567"},
+		{1234567, '\'', "1'234'567"},
+		{1234567, ' ', "1 234 567"},
+		{math.Inf(-1), ',', "-Inf"},
+		{math.NaN(), ',', "NaN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := FormatGrouped(tt.value, tt.separator); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
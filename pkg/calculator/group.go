@@ -0,0 +1,109 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// GroupValue is the value of a parenthesized sub-expression, with the
+// rune offsets of its parentheses, End being exclusive
+type GroupValue struct {
+	Start int
+	End   int
+	Value float64
+}
+
+// group is a parenthesized sub-expression spanning the tokens of the
+// reverse polish notation from first to end, exclusive
+type group struct {
+	GroupValue
+	first int
+	end   int
+	// entered is set while evaluating the group, evaluated once its
+	// value is known
+	entered   bool
+	evaluated bool
+}
+
+// EvaluateGroups evaluates the expression like EvaluateExpression, also
+// returning the value of each parenthesized sub-expression in the order
+// they are opened, e.g. for (1+2)*(3+4) the groups 3 at 0-5 and 7 at 6-11.
+// The parentheses of function calls are not groups, nor are those in the
+// branch of a conditional not taken.
+//
+// The values are those used by the evaluation, captured as it goes, so
+// that e.g. (random())*1 reports the same value as its result.
+func (e *Evaluator) EvaluateGroups(expression string) (float64, []GroupValue, error) {
+	e.setWarnings(nil)
+	tokens, err := e.tokenize(expression)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(tokens) == 0 {
+		return 0, nil, fmt.Errorf("no tokens found")
+	}
+	e.trace("tokens: %v", tokens)
+	polishNotation, spans, err := e.toReversePolishNotationGroups(tokens, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	e.trace("reverse polish notation: %v", polishNotation)
+	ev := &evaluation{ctx: context.Background(), groups: spans}
+	result, err := e.runExpression(ev, polishNotation, nil, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var groups []GroupValue
+	for _, g := range ev.groups {
+		if g.evaluated {
+			groups = append(groups, g.GroupValue)
+		}
+	}
+	slices.SortStableFunc(groups, func(a, b GroupValue) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+	return result, groups, nil
+}
+
+// reach notes that the evaluation reached index i of the reverse polish
+// notation, with the values of the groups ending there on the stack. A
+// jump may reach the end of a group without evaluating it.
+func (ev *evaluation) reach(i int, stack []float64) {
+	for j := range ev.groups {
+		g := &ev.groups[j]
+		if g.end == i && g.entered {
+			g.Value = stack[len(stack)-1]
+			g.entered, g.evaluated = false, true
+		}
+		if g.first == i {
+			g.entered = true
+		}
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEvaluateGroups(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+		groups     []GroupValue
+	}{
+		{"(1+2)*(3+4)", 21, []GroupValue{{0, 5, 3}, {6, 11, 7}}},
+		{"((1+2)*2)", 6, []GroupValue{{0, 9, 6}, {1, 6, 3}}},
+		{"sqrt(4) + (1)", 3, []GroupValue{{10, 13, 1}}},
+		{"[2 - 3] * 2", -2, []GroupValue{{0, 7, -1}}},
+		{"1 + 2", 3, nil},
+		// only the branch taken is evaluated
+		{"1 ? (5) : (6)", 5, []GroupValue{{4, 7, 5}}},
+		{"0 ? (5) : (6)", 6, []GroupValue{{10, 13, 6}}},
+		{"(1 ? 5 : 6) + (2)", 7, []GroupValue{{0, 11, 5}, {14, 17, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, groups, err := NewEvaluator(Options{}).EvaluateGroups(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(groups, tt.groups) {
+				t.Errorf("groups %v, want %v", groups, tt.groups)
+			}
+		})
+	}
+}
+
+func TestEvaluateGroupsSinglePass(t *testing.T) {
+	operations := 0
+	e := NewEvaluator(Options{AuditHook: func(op string, operands []float64, result float64) {
+		operations++
+	}})
+	if _, _, err := e.EvaluateGroups("(1+2)*(3+4)"); err != nil {
+		t.Fatal(err)
+	}
+	if operations != 3 {
+		t.Errorf("audited %d operations, want 3", operations)
+	}
+
+	// the group value is the one the result was computed from
+	got, groups, err := e.EvaluateGroups("(random())*1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].Value != got {
+		t.Errorf("groups %v, want the value of the result %v", groups, got)
+	}
+}
@@ -25,14 +25,25 @@
 package calculator
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"maps"
 	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+	"unicode"
 )
 
 type Precedence int
 
 const (
-	Normal Precedence = iota
+	// Comparison is below every other operator, e.g. of < and ==
+	Comparison Precedence = iota - 2
+	// Low is below the arithmetic operators, e.g. of the bitwise ones
+	Low
+	Normal
 	Middle
 	High
 )
@@ -43,8 +54,56 @@ const (
 	Infix Type = iota // + - * / ..
 	Function
 	Suffix // !
+	Prefix // neg, takes the single operand following it
 )
 
+type Assoc int
+
+const (
+	LeftAssoc  Assoc = iota // a - b - c = (a - b) - c
+	RightAssoc              // a ^ b ^ c = a ^ (b ^ c)
+)
+
+// AngleMode is the unit of angles taken or returned by trigonometric functions
+type AngleMode int
+
+const (
+	Radians  AngleMode = iota
+	Degrees            // 360 per full turn
+	Gradians           // 400 per full turn
+)
+
+// fullTurn returns the angle of a full turn
+func (m AngleMode) fullTurn() float64 {
+	switch m {
+	case Degrees:
+		return 360
+	case Gradians:
+		return 400
+	}
+	return 2 * math.Pi
+}
+
+func (m AngleMode) toRadians(angle float64) float64 {
+	switch m {
+	case Degrees:
+		return angle * math.Pi / 180
+	case Gradians:
+		return angle * math.Pi / 200
+	}
+	return angle
+}
+
+func (m AngleMode) fromRadians(angle float64) float64 {
+	switch m {
+	case Degrees:
+		return angle * 180 / math.Pi
+	case Gradians:
+		return angle * 200 / math.Pi
+	}
+	return angle
+}
+
 type OperatorEvaluator interface {
 	Evaluate(left, right float64) (float64, error)
 
@@ -54,33 +113,191 @@ type OperatorEvaluator interface {
 	Precedence() Precedence
 
 	Type() Type
+
+	// Associativity decides how a chain of operators with the same
+	// precedence is grouped, only meaningful for Infix operators
+	Associativity() Assoc
+}
+
+// MultiArgEvaluator is implemented by functions taking other than a single
+// argument, called with comma-separated arguments like atan2(y, x), or with
+// empty parentheses like random() if the minimum arity is zero
+type MultiArgEvaluator interface {
+	OperatorEvaluator
+
+	// Arity returns the minimum and maximum number of arguments,
+	// a negative maximum means there is no upper limit
+	Arity() (min, max int)
+
+	EvaluateArgs(args []float64) (float64, error)
+}
+
+// ZeroPowZeroPolicy decides the result of 0^0
+type ZeroPowZeroPolicy int
+
+const (
+	ZeroPowZeroOne   ZeroPowZeroPolicy = iota // 0^0 = 1, same as math.Pow
+	ZeroPowZeroError                          // 0^0 is an error
+	ZeroPowZeroNaN                            // 0^0 = NaN
+)
+
+// NaNPolicy decides what happens when an operation produces NaN
+type NaNPolicy int
+
+const (
+	NaNPropagate NaNPolicy = iota // NaN + 1 = NaN, as in IEEE 754
+	NaNError                      // any NaN result is an error
+)
+
+// angleEvaluator is implemented by trigonometric evaluators, which work
+// in radians and need their operand or result converted for the AngleMode
+type angleEvaluator interface {
+	// inverse returns true if the result is an angle rather than the operand
+	inverse() bool
 }
 
+// domainEvaluator is implemented by functions not defined for every real number
+type domainEvaluator interface {
+	// domain returns the domain as written in errors and whether x is in it
+	domain(x float64) (string, bool)
+}
+
+// quarterTurnEvaluator is implemented by trigonometric functions with
+// exact values at multiples of a quarter turn, which are not exact in
+// radians, e.g. sin(180) in degrees is 0 rather than 1.2e-16
+type quarterTurnEvaluator interface {
+	// quarterTurn returns the value at n quarter turns, n in [0, 3]
+	quarterTurn(n int) float64
+}
+
+// turnEvaluator is implemented by functions mapping an angle to an angle,
+// computed in the unit of the AngleMode so that e.g. 370 degrees is
+// normalized to exactly 10
+type turnEvaluator interface {
+	evaluateTurn(angle, fullTurn float64) float64
+}
+
+// clockEvaluator is implemented by functions of the current time, which
+// is read from the Evaluator.Clock
+type clockEvaluator interface {
+	evaluateAt(now time.Time) float64
+}
+
+// contextEvaluator is implemented by functions that may loop for long,
+// which stop once the context of the evaluation is done
+type contextEvaluator interface {
+	evaluateContext(ctx context.Context, args []float64) (float64, error)
+}
+
+// contextCheckInterval is the number of loop iterations between checks
+// of the context in a contextEvaluator
+const contextCheckInterval = 1 << 16
+
+const (
+	// maxFactorial is the largest n whose n! fits in a float64
+	maxFactorial = 170
+	// maxDoubleFactorial is the largest n whose n!! fits in a float64
+	maxDoubleFactorial = 300
+)
+
 type OperatorEvaluatorFactory interface {
 	Create(operator string) OperatorEvaluator
 
 	IsValid(operator string) bool
+
+	// RegisterOperator adds an operator or function, written as a name like
+	// gcd or as symbols like @. Returns an error if the symbol cannot be
+	// lexed as one token or is already registered.
+	RegisterOperator(symbol string, evaluator OperatorEvaluator) error
+
+	// ReplaceOperator registers an operator or function like
+	// RegisterOperator, overwriting the one of the symbol if any, built-in
+	// or not, e.g. to make / an integer division.
+	ReplaceOperator(symbol string, evaluator OperatorEvaluator) error
 }
 
 // NewOperatorEvaluatorFactory creates a new instance of OperatorEvaluatorFactory
 //
 // Supports operator evaluation for:
 //
-// - - * / % ^ ! sqrt log sin cos tan
+//   - operators: + - * / % ^ ** ! !! ² ³ ± & | xor << >> < > <= >= == !=
+//   - operators the tokenizer turns signs into, which cannot be typed by
+//     name: percent neg plusminus
+//   - functions: sqrt inv abs floor ceil round log log10 log2 logb exp10 exp2
+//     sigmoid relu softplus
+//     sin cos tan asin acos atan atan2 normangle refangle
+//     dot cross2 argmax argmin max min wmean pctof gcd comb binompmf isprime
+//     c2f f2c c2k k2c random now
 func NewOperatorEvaluatorFactory() OperatorEvaluatorFactory {
 	operators := map[string]OperatorEvaluator{
-		"+":    additionEvaluator{},
-		"-":    subtractionEvaluator{},
-		"*":    multiplicationEvaluator{},
-		"/":    divisionEvaluator{},
-		"%":    remainderEvaluator{},
-		"^":    powerEvaluator{},
-		"!":    factorialEvaluator{},
-		"sqrt": sqrtEvaluator{},
-		"log":  logarithmEvaluator{},
-		"sin":  sinEvaluator{},
-		"cos":  cosEvaluator{},
-		"tan":  tanEvaluator{},
+		"+":         additionEvaluator{},
+		"-":         subtractionEvaluator{},
+		"*":         multiplicationEvaluator{},
+		"/":         divisionEvaluator{},
+		"%":         remainderEvaluator{},
+		"^":         powerEvaluator{},
+		"**":        powerEvaluator{},
+		"!":         factorialEvaluator{},
+		"!!":        doubleFactorialEvaluator{},
+		"²":         squareEvaluator{},
+		"³":         cubeEvaluator{},
+		"percent":   percentEvaluator{},
+		"neg":       negationEvaluator{},
+		"±":         plusMinusEvaluator{},
+		"plusminus": prefixPlusMinusEvaluator{},
+		"&":         bitwiseAndEvaluator{},
+		"|":         bitwiseOrEvaluator{},
+		"xor":       bitwiseXorEvaluator{},
+		"<<":        shiftLeftEvaluator{},
+		">>":        shiftRightEvaluator{},
+		"<":         lessEvaluator{},
+		">":         greaterEvaluator{},
+		"<=":        lessEqualEvaluator{},
+		">=":        greaterEqualEvaluator{},
+		"==":        equalEvaluator{},
+		"!=":        notEqualEvaluator{},
+		"sqrt":      sqrtEvaluator{},
+		"inv":       reciprocalEvaluator{},
+		"abs":       absEvaluator{},
+		"floor":     floorEvaluator{},
+		"ceil":      ceilEvaluator{},
+		"round":     roundEvaluator{},
+		"log":       logarithmEvaluator{},
+		"log10":     log10Evaluator{},
+		"log2":      log2Evaluator{},
+		"logb":      logbEvaluator{},
+		"exp10":     exp10Evaluator{},
+		"exp2":      exp2Evaluator{},
+		"sigmoid":   sigmoidEvaluator{},
+		"relu":      reluEvaluator{},
+		"softplus":  softplusEvaluator{},
+		"sin":       sinEvaluator{},
+		"cos":       cosEvaluator{},
+		"tan":       tanEvaluator{},
+		"asin":      asinEvaluator{},
+		"acos":      acosEvaluator{},
+		"atan":      atanEvaluator{},
+		"atan2":     atan2Evaluator{},
+		"normangle": normalizeAngleEvaluator{},
+		"refangle":  referenceAngleEvaluator{},
+		"dot":       dotEvaluator{},
+		"cross2":    cross2Evaluator{},
+		"argmax":    argmaxEvaluator{},
+		"argmin":    argminEvaluator{},
+		"max":       maxEvaluator{},
+		"min":       minEvaluator{},
+		"wmean":     weightedMeanEvaluator{},
+		"pctof":     percentOfEvaluator{},
+		"gcd":       gcdEvaluator{},
+		"comb":      combinationEvaluator{},
+		"binompmf":  binomialPMFEvaluator{},
+		"isprime":   isPrimeEvaluator{},
+		"c2f":       celsiusToFahrenheitEvaluator{},
+		"f2c":       fahrenheitToCelsiusEvaluator{},
+		"c2k":       celsiusToKelvinEvaluator{},
+		"k2c":       kelvinToCelsiusEvaluator{},
+		"random":    randomEvaluator{},
+		"now":       nowEvaluator{},
 	}
 	return &operatorEvaluatorFactory{
 		evaluators: operators,
@@ -101,6 +318,51 @@ func (f *operatorEvaluatorFactory) Create(operator string) OperatorEvaluator {
 	return f.evaluators[operator]
 }
 
+func (f *operatorEvaluatorFactory) RegisterOperator(symbol string, evaluator OperatorEvaluator) error {
+	return f.register(symbol, evaluator, false)
+}
+
+func (f *operatorEvaluatorFactory) ReplaceOperator(symbol string, evaluator OperatorEvaluator) error {
+	return f.register(symbol, evaluator, true)
+}
+
+// register adds the operator, replacing a registered one only if overwrite
+func (f *operatorEvaluatorFactory) register(symbol string, evaluator OperatorEvaluator, overwrite bool) error {
+	if symbol == "" {
+		return errors.New("operator symbol cannot be empty")
+	}
+	if evaluator == nil {
+		return fmt.Errorf("operator %s has no evaluator", symbol)
+	}
+	if !isOperatorSymbol(symbol) {
+		return fmt.Errorf("invalid operator symbol %q, expected a name or only symbols", symbol)
+	}
+	if f.IsValid(symbol) && !overwrite {
+		return fmt.Errorf("operator %s is already registered", symbol)
+	}
+	f.evaluators[symbol] = evaluator
+	return nil
+}
+
+// isOperatorSymbol returns true if the lexer reads s as a single name or
+// run of symbols, rather than splitting it or taking it for a number
+func isOperatorSymbol(s string) bool {
+	if namePattern.MatchString(s) {
+		return true
+	}
+	for _, c := range s {
+		if char(c).isNumber() || char(c).isLetter() || char(c).isParen() ||
+			separators[c] != "" || unicode.IsSpace(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *operatorEvaluatorFactory) Operators() []string {
+	return slices.Sorted(maps.Keys(f.evaluators))
+}
+
 type (
 	additionEvaluator struct {
 	}
@@ -116,18 +378,153 @@ type (
 	}
 	factorialEvaluator struct {
 	}
+	doubleFactorialEvaluator struct {
+	}
+	squareEvaluator struct {
+	}
+	cubeEvaluator struct {
+	}
+	percentEvaluator struct {
+	}
+	negationEvaluator struct {
+	}
+	plusMinusEvaluator struct {
+	}
+	prefixPlusMinusEvaluator struct {
+	}
+	bitwiseAndEvaluator struct {
+	}
+	bitwiseOrEvaluator struct {
+	}
+	bitwiseXorEvaluator struct {
+	}
+	shiftLeftEvaluator struct {
+	}
+	shiftRightEvaluator struct {
+	}
+	lessEvaluator struct {
+	}
+	greaterEvaluator struct {
+	}
+	lessEqualEvaluator struct {
+	}
+	greaterEqualEvaluator struct {
+	}
+	equalEvaluator struct {
+	}
+	notEqualEvaluator struct {
+	}
 	sqrtEvaluator struct {
 	}
+	reciprocalEvaluator struct {
+	}
+	absEvaluator struct {
+	}
+	floorEvaluator struct {
+	}
+	ceilEvaluator struct {
+	}
+	roundEvaluator struct {
+	}
 	logarithmEvaluator struct {
 	}
+	log10Evaluator struct {
+	}
+	log2Evaluator struct {
+	}
+	logbEvaluator struct {
+	}
+	exp10Evaluator struct {
+	}
+	exp2Evaluator struct {
+	}
+	sigmoidEvaluator struct {
+	}
+	reluEvaluator struct {
+	}
+	softplusEvaluator struct {
+	}
 	sinEvaluator struct {
 	}
 	cosEvaluator struct {
 	}
 	tanEvaluator struct {
 	}
+	asinEvaluator struct {
+	}
+	acosEvaluator struct {
+	}
+	atanEvaluator struct {
+	}
+	atan2Evaluator struct {
+	}
+	normalizeAngleEvaluator struct {
+	}
+	referenceAngleEvaluator struct {
+	}
+	dotEvaluator struct {
+	}
+	cross2Evaluator struct {
+	}
+	argmaxEvaluator struct {
+	}
+	argminEvaluator struct {
+	}
+	maxEvaluator struct {
+	}
+	minEvaluator struct {
+	}
+	weightedMeanEvaluator struct {
+	}
+	percentOfEvaluator struct {
+	}
+	gcdEvaluator struct {
+	}
+	combinationEvaluator struct {
+	}
+	binomialPMFEvaluator struct {
+	}
+	isPrimeEvaluator struct {
+	}
+	celsiusToFahrenheitEvaluator struct {
+	}
+	fahrenheitToCelsiusEvaluator struct {
+	}
+	celsiusToKelvinEvaluator struct {
+	}
+	kelvinToCelsiusEvaluator struct {
+	}
+	randomEvaluator struct {
+	}
+	nowEvaluator struct {
+	}
 )
 
+// truth returns 1 if b is true, otherwise 0, the result of comparisons
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// integerEpsilon is how far an argument may be from an integer to still be
+// taken as one, absorbing floating point noise like 2.9999999999999996
+const integerEpsilon = 1e-9
+
+// requireInteger returns the argument of the function rounded to an integer,
+// or a uniform error if it is not one
+func requireInteger(function string, arg float64) (int64, error) {
+	rounded := math.Round(arg)
+	if math.IsNaN(arg) || math.Abs(arg-rounded) > integerEpsilon {
+		return 0, fmt.Errorf("function %s requires an integer argument", function)
+	}
+	if rounded >= math.MaxInt64 || rounded < math.MinInt64 {
+		return 0, fmt.Errorf("function %s argument %v is out of integer range", function, arg)
+	}
+	return int64(rounded), nil
+}
+
 func (e additionEvaluator) Evaluate(left, right float64) (float64, error) {
 	return left + right, nil
 }
@@ -144,6 +541,10 @@ func (e additionEvaluator) Type() Type {
 	return Infix
 }
 
+func (e additionEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
 func (e subtractionEvaluator) Evaluate(left, right float64) (float64, error) {
 	return left - right, nil
 }
@@ -160,6 +561,10 @@ func (e subtractionEvaluator) Type() Type {
 	return Infix
 }
 
+func (e subtractionEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
 func (e multiplicationEvaluator) Evaluate(left, right float64) (float64, error) {
 	return left * right, nil
 }
@@ -169,13 +574,17 @@ func (e multiplicationEvaluator) Supports(operator string) bool {
 }
 
 func (e multiplicationEvaluator) Precedence() Precedence {
-	return Normal
+	return Middle
 }
 
 func (e multiplicationEvaluator) Type() Type {
 	return Infix
 }
 
+func (e multiplicationEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
 func (e divisionEvaluator) Evaluate(left, right float64) (float64, error) {
 	if right == 0 {
 		return 0, errors.New("division by zero")
@@ -195,7 +604,14 @@ func (e divisionEvaluator) Type() Type {
 	return Infix
 }
 
+func (e divisionEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
 func (r remainderEvaluator) Evaluate(left, right float64) (float64, error) {
+	if right == 0 {
+		return 0, errors.New("modulo by zero")
+	}
 	return math.Mod(left, right), nil
 }
 
@@ -211,12 +627,16 @@ func (r remainderEvaluator) Type() Type {
 	return Infix
 }
 
+func (r remainderEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
 func (e powerEvaluator) Evaluate(left, right float64) (float64, error) {
 	return math.Pow(left, right), nil
 }
 
 func (e powerEvaluator) Supports(operator string) bool {
-	return operator == "^"
+	return operator == "^" || operator == "**"
 }
 
 func (e powerEvaluator) Precedence() Precedence {
@@ -227,7 +647,20 @@ func (e powerEvaluator) Type() Type {
 	return Infix
 }
 
+func (e powerEvaluator) Associativity() Assoc {
+	return RightAssoc
+}
+
 func (e factorialEvaluator) Evaluate(left, right float64) (float64, error) {
+	if left != math.Trunc(left) {
+		return 0, fmt.Errorf("factorial of non-integer %v", left)
+	}
+	if left < 0 {
+		return 0, fmt.Errorf("factorial of negative number %v", left)
+	}
+	if left > maxFactorial {
+		return 0, fmt.Errorf("factorial of %v overflows, the largest is %d!", left, maxFactorial)
+	}
 	var result float64 = 1
 	for i := 1; i <= int(left); i++ {
 		result *= float64(i)
@@ -247,83 +680,1668 @@ func (e factorialEvaluator) Type() Type {
 	return Suffix
 }
 
-func (e sqrtEvaluator) Evaluate(left, right float64) (float64, error) {
-	return math.Sqrt(left), nil
+func (e factorialEvaluator) Associativity() Assoc {
+	return LeftAssoc
 }
 
-func (e sqrtEvaluator) Supports(operator string) bool {
-	return operator == "sqrt"
+func (e doubleFactorialEvaluator) Evaluate(left, right float64) (float64, error) {
+	if left != math.Trunc(left) {
+		return 0, fmt.Errorf("double factorial of non-integer %v", left)
+	}
+	if left < 0 {
+		return 0, fmt.Errorf("double factorial of negative number %v", left)
+	}
+	if left > maxDoubleFactorial {
+		return 0, fmt.Errorf("double factorial of %v overflows, the largest is %d!!", left, maxDoubleFactorial)
+	}
+	var result float64 = 1
+	for i := int(left); i > 1; i -= 2 {
+		result *= float64(i)
+	}
+	return result, nil
 }
 
-func (e sqrtEvaluator) Precedence() Precedence {
+func (e doubleFactorialEvaluator) Supports(operator string) bool {
+	return operator == "!!"
+}
+
+func (e doubleFactorialEvaluator) Precedence() Precedence {
 	return High
 }
 
-func (e sqrtEvaluator) Type() Type {
-	return Function
+func (e doubleFactorialEvaluator) Type() Type {
+	return Suffix
 }
 
-func (e logarithmEvaluator) Evaluate(left, right float64) (float64, error) {
-	return math.Log(left), nil
+func (e doubleFactorialEvaluator) Associativity() Assoc {
+	return LeftAssoc
 }
 
-func (e logarithmEvaluator) Supports(operator string) bool {
-	return operator == "log"
+func (e squareEvaluator) Evaluate(left, right float64) (float64, error) {
+	return left * left, nil
 }
 
-func (e logarithmEvaluator) Precedence() Precedence {
+func (e squareEvaluator) Supports(operator string) bool {
+	return operator == "²"
+}
+
+func (e squareEvaluator) Precedence() Precedence {
 	return High
+}
 
+func (e squareEvaluator) Type() Type {
+	return Suffix
 }
 
-func (e logarithmEvaluator) Type() Type {
-	return Function
+func (e squareEvaluator) Associativity() Assoc {
+	return LeftAssoc
 }
 
-func (e sinEvaluator) Evaluate(left, right float64) (float64, error) {
-	return math.Sin(left), nil
+func (e cubeEvaluator) Evaluate(left, right float64) (float64, error) {
+	return left * left * left, nil
 }
 
-func (e sinEvaluator) Supports(operator string) bool {
-	return operator == "sin"
+func (e cubeEvaluator) Supports(operator string) bool {
+	return operator == "³"
 }
 
-func (e sinEvaluator) Precedence() Precedence {
+func (e cubeEvaluator) Precedence() Precedence {
 	return High
 }
 
-func (e sinEvaluator) Type() Type {
-	return Function
+func (e cubeEvaluator) Type() Type {
+	return Suffix
 }
 
-func (e cosEvaluator) Supports(operator string) bool {
-	return operator == "cos"
+func (e cubeEvaluator) Associativity() Assoc {
+	return LeftAssoc
 }
 
-func (e cosEvaluator) Precedence() Precedence {
+// Evaluate returns the percentage as a fraction, 50% is 0.5
+func (e percentEvaluator) Evaluate(left, right float64) (float64, error) {
+	return left / 100, nil
+}
+
+func (e percentEvaluator) Supports(operator string) bool {
+	return operator == "percent"
+}
+
+func (e percentEvaluator) Precedence() Precedence {
 	return High
 }
 
-func (e cosEvaluator) Type() Type {
-	return Function
+func (e percentEvaluator) Type() Type {
+	return Suffix
 }
 
-func (e cosEvaluator) Evaluate(left, right float64) (float64, error) {
-	return math.Cos(left), nil
+func (e percentEvaluator) Associativity() Assoc {
+	return LeftAssoc
 }
 
-func (e tanEvaluator) Evaluate(left, right float64) (float64, error) {
-	return math.Tan(left), nil
+// negationEvaluator is the unary minus, it binds looser than ^ so that
+// -2^2 = -(2^2) = -4, the same as in written math and most calculators
+func (e negationEvaluator) Evaluate(left, right float64) (float64, error) {
+	return -left, nil
 }
 
-func (e tanEvaluator) Supports(operator string) bool {
-	return operator == "tan"
+func (e negationEvaluator) Supports(operator string) bool {
+	return operator == "neg"
 }
 
-func (e tanEvaluator) Precedence() Precedence {
-	return High
+func (e negationEvaluator) Precedence() Precedence {
+	return Middle
 }
 
-func (e tanEvaluator) Type() Type {
-	return Function
+func (e negationEvaluator) Type() Type {
+	return Prefix
+}
+
+func (e negationEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns the principal value a + b of a ± b, see EvaluateAll for both
+func (e plusMinusEvaluator) Evaluate(left, right float64) (float64, error) {
+	return left + right, nil
+}
+
+func (e plusMinusEvaluator) Supports(operator string) bool {
+	return operator == "±"
+}
+
+func (e plusMinusEvaluator) Precedence() Precedence {
+	return Normal
+}
+
+func (e plusMinusEvaluator) Type() Type {
+	return Infix
+}
+
+func (e plusMinusEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns the principal value x of the unary ±x, see EvaluateAll
+func (e prefixPlusMinusEvaluator) Evaluate(left, right float64) (float64, error) {
+	return left, nil
+}
+
+func (e prefixPlusMinusEvaluator) Supports(operator string) bool {
+	return operator == "plusminus"
+}
+
+func (e prefixPlusMinusEvaluator) Precedence() Precedence {
+	return Middle
+}
+
+func (e prefixPlusMinusEvaluator) Type() Type {
+	return Prefix
+}
+
+func (e prefixPlusMinusEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// integerOperands converts the operands of a bitwise operator to integers,
+// returning an error unless both are whole numbers in the int64 range
+func integerOperands(operator string, left, right float64) (int64, int64, error) {
+	for _, operand := range []float64{left, right} {
+		if math.IsNaN(operand) || operand != math.Trunc(operand) {
+			return 0, 0, fmt.Errorf("operator %s requires integer operands, got %v", operator, operand)
+		}
+		if operand >= math.MaxInt64 || operand < math.MinInt64 {
+			return 0, 0, fmt.Errorf("operand %v of operator %s is out of integer range", operand, operator)
+		}
+	}
+	return int64(left), int64(right), nil
+}
+
+// Evaluate returns the bits set in both operands
+func (e bitwiseAndEvaluator) Evaluate(left, right float64) (float64, error) {
+	a, b, err := integerOperands("&", left, right)
+	if err != nil {
+		return 0, err
+	}
+	return float64(a & b), nil
+}
+
+func (e bitwiseAndEvaluator) Supports(operator string) bool {
+	return operator == "&"
+}
+
+func (e bitwiseAndEvaluator) Precedence() Precedence {
+	return Low
+}
+
+func (e bitwiseAndEvaluator) Type() Type {
+	return Infix
+}
+
+func (e bitwiseAndEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns the bits set in either operand
+func (e bitwiseOrEvaluator) Evaluate(left, right float64) (float64, error) {
+	a, b, err := integerOperands("|", left, right)
+	if err != nil {
+		return 0, err
+	}
+	return float64(a | b), nil
+}
+
+func (e bitwiseOrEvaluator) Supports(operator string) bool {
+	return operator == "|"
+}
+
+func (e bitwiseOrEvaluator) Precedence() Precedence {
+	return Low
+}
+
+func (e bitwiseOrEvaluator) Type() Type {
+	return Infix
+}
+
+func (e bitwiseOrEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns the bits set in exactly one operand
+func (e bitwiseXorEvaluator) Evaluate(left, right float64) (float64, error) {
+	a, b, err := integerOperands("xor", left, right)
+	if err != nil {
+		return 0, err
+	}
+	return float64(a ^ b), nil
+}
+
+func (e bitwiseXorEvaluator) Supports(operator string) bool {
+	return operator == "xor"
+}
+
+func (e bitwiseXorEvaluator) Precedence() Precedence {
+	return Low
+}
+
+func (e bitwiseXorEvaluator) Type() Type {
+	return Infix
+}
+
+func (e bitwiseXorEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e shiftLeftEvaluator) Evaluate(left, right float64) (float64, error) {
+	a, n, err := integerOperands("<<", left, right)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("negative shift count %d", n)
+	}
+	return float64(a << n), nil
+}
+
+func (e shiftLeftEvaluator) Supports(operator string) bool {
+	return operator == "<<"
+}
+
+func (e shiftLeftEvaluator) Precedence() Precedence {
+	return Low
+}
+
+func (e shiftLeftEvaluator) Type() Type {
+	return Infix
+}
+
+func (e shiftLeftEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e shiftRightEvaluator) Evaluate(left, right float64) (float64, error) {
+	a, n, err := integerOperands(">>", left, right)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("negative shift count %d", n)
+	}
+	return float64(a >> n), nil
+}
+
+func (e shiftRightEvaluator) Supports(operator string) bool {
+	return operator == ">>"
+}
+
+func (e shiftRightEvaluator) Precedence() Precedence {
+	return Low
+}
+
+func (e shiftRightEvaluator) Type() Type {
+	return Infix
+}
+
+func (e shiftRightEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns 1 if left is less than right, otherwise 0
+func (e lessEvaluator) Evaluate(left, right float64) (float64, error) {
+	return truth(left < right), nil
+}
+
+func (e lessEvaluator) Supports(operator string) bool {
+	return operator == "<"
+}
+
+func (e lessEvaluator) Precedence() Precedence {
+	return Comparison
+}
+
+func (e lessEvaluator) Type() Type {
+	return Infix
+}
+
+func (e lessEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns 1 if left is greater than right, otherwise 0
+func (e greaterEvaluator) Evaluate(left, right float64) (float64, error) {
+	return truth(left > right), nil
+}
+
+func (e greaterEvaluator) Supports(operator string) bool {
+	return operator == ">"
+}
+
+func (e greaterEvaluator) Precedence() Precedence {
+	return Comparison
+}
+
+func (e greaterEvaluator) Type() Type {
+	return Infix
+}
+
+func (e greaterEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns 1 if left is less than or equal to right, otherwise 0
+func (e lessEqualEvaluator) Evaluate(left, right float64) (float64, error) {
+	return truth(left <= right), nil
+}
+
+func (e lessEqualEvaluator) Supports(operator string) bool {
+	return operator == "<="
+}
+
+func (e lessEqualEvaluator) Precedence() Precedence {
+	return Comparison
+}
+
+func (e lessEqualEvaluator) Type() Type {
+	return Infix
+}
+
+func (e lessEqualEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns 1 if left is greater than or equal to right, otherwise 0
+func (e greaterEqualEvaluator) Evaluate(left, right float64) (float64, error) {
+	return truth(left >= right), nil
+}
+
+func (e greaterEqualEvaluator) Supports(operator string) bool {
+	return operator == ">="
+}
+
+func (e greaterEqualEvaluator) Precedence() Precedence {
+	return Comparison
+}
+
+func (e greaterEqualEvaluator) Type() Type {
+	return Infix
+}
+
+func (e greaterEqualEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns 1 if left is exactly equal to right, otherwise 0
+func (e equalEvaluator) Evaluate(left, right float64) (float64, error) {
+	return truth(left == right), nil
+}
+
+func (e equalEvaluator) Supports(operator string) bool {
+	return operator == "=="
+}
+
+func (e equalEvaluator) Precedence() Precedence {
+	return Comparison
+}
+
+func (e equalEvaluator) Type() Type {
+	return Infix
+}
+
+func (e equalEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns 1 if left is not equal to right, otherwise 0
+func (e notEqualEvaluator) Evaluate(left, right float64) (float64, error) {
+	return truth(left != right), nil
+}
+
+func (e notEqualEvaluator) Supports(operator string) bool {
+	return operator == "!="
+}
+
+func (e notEqualEvaluator) Precedence() Precedence {
+	return Comparison
+}
+
+func (e notEqualEvaluator) Type() Type {
+	return Infix
+}
+
+func (e notEqualEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e sqrtEvaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Sqrt(left), nil
+}
+
+func (e sqrtEvaluator) domain(x float64) (string, bool) {
+	return "[0,+inf)", x >= 0
+}
+
+func (e sqrtEvaluator) Supports(operator string) bool {
+	return operator == "sqrt"
+}
+
+func (e sqrtEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e sqrtEvaluator) Type() Type {
+	return Function
+}
+
+func (e sqrtEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e reciprocalEvaluator) Evaluate(left, right float64) (float64, error) {
+	if left == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return 1 / left, nil
+}
+
+func (e reciprocalEvaluator) Supports(operator string) bool {
+	return operator == "inv"
+}
+
+func (e reciprocalEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e reciprocalEvaluator) Type() Type {
+	return Function
+}
+
+func (e reciprocalEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e absEvaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Abs(left), nil
+}
+
+func (e absEvaluator) Supports(operator string) bool {
+	return operator == "abs"
+}
+
+func (e absEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e absEvaluator) Type() Type {
+	return Function
+}
+
+func (e absEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e floorEvaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Floor(left), nil
+}
+
+func (e floorEvaluator) Supports(operator string) bool {
+	return operator == "floor"
+}
+
+func (e floorEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e floorEvaluator) Type() Type {
+	return Function
+}
+
+func (e floorEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e ceilEvaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Ceil(left), nil
+}
+
+func (e ceilEvaluator) Supports(operator string) bool {
+	return operator == "ceil"
+}
+
+func (e ceilEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e ceilEvaluator) Type() Type {
+	return Function
+}
+
+func (e ceilEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate rounds half away from zero, e.g. 2.5 to 3 and -2.5 to -3
+func (e roundEvaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Round(left), nil
+}
+
+func (e roundEvaluator) Supports(operator string) bool {
+	return operator == "round"
+}
+
+func (e roundEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e roundEvaluator) Type() Type {
+	return Function
+}
+
+func (e roundEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e logarithmEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left})
+}
+
+// EvaluateArgs returns the natural logarithm of log(x), or the logarithm
+// in the given base of log(base, x)
+func (e logarithmEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	if len(args) == 1 {
+		return math.Log(args[0]), nil
+	}
+	return logBase("log", args[1], args[0])
+}
+
+// logBase returns the logarithm of x in the given base, which must be
+// positive and other than 1
+func logBase(function string, x, base float64) (float64, error) {
+	if base <= 0 || base == 1 {
+		return 0, fmt.Errorf("%s: invalid base %v, it must be positive and not 1", function, base)
+	}
+	result := math.Log(x) / math.Log(base)
+	// keep exact powers exact, e.g. log(10, 1000) is 3 rather than 2.9999999999999996
+	if rounded := math.Round(result); math.Pow(base, rounded) == x {
+		return rounded, nil
+	}
+	return result, nil
+}
+
+func (e logarithmEvaluator) Arity() (int, int) {
+	return 1, 2
+}
+
+func (e logarithmEvaluator) domain(x float64) (string, bool) {
+	return "(0,+inf)", x > 0
+}
+
+func (e logarithmEvaluator) Supports(operator string) bool {
+	return operator == "log"
+}
+
+func (e logarithmEvaluator) Precedence() Precedence {
+	return High
+
+}
+
+func (e logarithmEvaluator) Type() Type {
+	return Function
+}
+
+func (e logarithmEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e log10Evaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Log10(left), nil
+}
+
+func (e log10Evaluator) domain(x float64) (string, bool) {
+	return "(0,+inf)", x > 0
+}
+
+func (e log10Evaluator) Supports(operator string) bool {
+	return operator == "log10"
+}
+
+func (e log10Evaluator) Precedence() Precedence {
+	return High
+}
+
+func (e log10Evaluator) Type() Type {
+	return Function
+}
+
+func (e log10Evaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e log2Evaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Log2(left), nil
+}
+
+func (e log2Evaluator) domain(x float64) (string, bool) {
+	return "(0,+inf)", x > 0
+}
+
+func (e log2Evaluator) Supports(operator string) bool {
+	return operator == "log2"
+}
+
+func (e log2Evaluator) Precedence() Precedence {
+	return High
+}
+
+func (e log2Evaluator) Type() Type {
+	return Function
+}
+
+func (e log2Evaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e logbEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left, right})
+}
+
+// EvaluateArgs returns the logarithm of x in the given base, logb(x, base)
+func (e logbEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	return logBase("logb", args[0], args[1])
+}
+
+func (e logbEvaluator) Arity() (int, int) {
+	return 2, 2
+}
+
+func (e logbEvaluator) Supports(operator string) bool {
+	return operator == "logb"
+}
+
+func (e logbEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e logbEvaluator) Type() Type {
+	return Function
+}
+
+func (e logbEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e exp10Evaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Pow(10, left), nil
+}
+
+func (e exp10Evaluator) Supports(operator string) bool {
+	return operator == "exp10"
+}
+
+func (e exp10Evaluator) Precedence() Precedence {
+	return High
+}
+
+func (e exp10Evaluator) Type() Type {
+	return Function
+}
+
+func (e exp10Evaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e exp2Evaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Exp2(left), nil
+}
+
+func (e exp2Evaluator) Supports(operator string) bool {
+	return operator == "exp2"
+}
+
+func (e exp2Evaluator) Precedence() Precedence {
+	return High
+}
+
+func (e exp2Evaluator) Type() Type {
+	return Function
+}
+
+func (e exp2Evaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns the logistic function 1 / (1 + e^-x)
+func (e sigmoidEvaluator) Evaluate(left, right float64) (float64, error) {
+	// exp of a negative number only, so it cannot overflow
+	if left >= 0 {
+		return 1 / (1 + math.Exp(-left)), nil
+	}
+	exp := math.Exp(left)
+	return exp / (1 + exp), nil
+}
+
+func (e sigmoidEvaluator) Supports(operator string) bool {
+	return operator == "sigmoid"
+}
+
+func (e sigmoidEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e sigmoidEvaluator) Type() Type {
+	return Function
+}
+
+func (e sigmoidEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns x if positive, otherwise 0
+func (e reluEvaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Max(0, left), nil
+}
+
+func (e reluEvaluator) Supports(operator string) bool {
+	return operator == "relu"
+}
+
+func (e reluEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e reluEvaluator) Type() Type {
+	return Function
+}
+
+func (e reluEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns log(1 + e^x), a smooth relu
+func (e softplusEvaluator) Evaluate(left, right float64) (float64, error) {
+	// log(1 + e^x) = max(x, 0) + log(1 + e^-|x|), which cannot overflow
+	// and keeps the precision of small results
+	return math.Max(left, 0) + math.Log1p(math.Exp(-math.Abs(left))), nil
+}
+
+func (e softplusEvaluator) Supports(operator string) bool {
+	return operator == "softplus"
+}
+
+func (e softplusEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e softplusEvaluator) Type() Type {
+	return Function
+}
+
+func (e softplusEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e sinEvaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Sin(left), nil
+}
+
+func (e sinEvaluator) Supports(operator string) bool {
+	return operator == "sin"
+}
+
+func (e sinEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e sinEvaluator) Type() Type {
+	return Function
+}
+
+func (e sinEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e sinEvaluator) inverse() bool {
+	return false
+}
+
+func (e sinEvaluator) quarterTurn(n int) float64 {
+	return [...]float64{0, 1, 0, -1}[n]
+}
+
+func (e cosEvaluator) Supports(operator string) bool {
+	return operator == "cos"
+}
+
+func (e cosEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e cosEvaluator) Type() Type {
+	return Function
+}
+
+func (e cosEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e cosEvaluator) inverse() bool {
+	return false
+}
+
+func (e cosEvaluator) quarterTurn(n int) float64 {
+	return [...]float64{1, 0, -1, 0}[n]
+}
+
+func (e cosEvaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Cos(left), nil
+}
+
+func (e tanEvaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Tan(left), nil
+}
+
+func (e tanEvaluator) Supports(operator string) bool {
+	return operator == "tan"
+}
+
+func (e tanEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e tanEvaluator) Type() Type {
+	return Function
+}
+
+func (e tanEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e tanEvaluator) inverse() bool {
+	return false
+}
+
+func (e tanEvaluator) quarterTurn(n int) float64 {
+	return [...]float64{0, math.Inf(1), 0, math.Inf(-1)}[n]
+}
+
+func (e asinEvaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Asin(left), nil
+}
+
+func (e asinEvaluator) domain(x float64) (string, bool) {
+	return "[-1,1]", x >= -1 && x <= 1
+}
+
+func (e asinEvaluator) Supports(operator string) bool {
+	return operator == "asin"
+}
+
+func (e asinEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e asinEvaluator) Type() Type {
+	return Function
+}
+
+func (e asinEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e asinEvaluator) inverse() bool {
+	return true
+}
+
+func (e acosEvaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Acos(left), nil
+}
+
+func (e acosEvaluator) domain(x float64) (string, bool) {
+	return "[-1,1]", x >= -1 && x <= 1
+}
+
+func (e acosEvaluator) Supports(operator string) bool {
+	return operator == "acos"
+}
+
+func (e acosEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e acosEvaluator) Type() Type {
+	return Function
+}
+
+func (e acosEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e acosEvaluator) inverse() bool {
+	return true
+}
+
+func (e atanEvaluator) Evaluate(left, right float64) (float64, error) {
+	return math.Atan(left), nil
+}
+
+func (e atanEvaluator) Supports(operator string) bool {
+	return operator == "atan"
+}
+
+func (e atanEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e atanEvaluator) Type() Type {
+	return Function
+}
+
+func (e atanEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e atanEvaluator) inverse() bool {
+	return true
+}
+
+func (e atan2Evaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left, right})
+}
+
+// EvaluateArgs returns the angle of the point (x, y) from atan2(y, x)
+func (e atan2Evaluator) EvaluateArgs(args []float64) (float64, error) {
+	return math.Atan2(args[0], args[1]), nil
+}
+
+func (e atan2Evaluator) Arity() (int, int) {
+	return 2, 2
+}
+
+func (e atan2Evaluator) Supports(operator string) bool {
+	return operator == "atan2"
+}
+
+func (e atan2Evaluator) Precedence() Precedence {
+	return High
+}
+
+func (e atan2Evaluator) Type() Type {
+	return Function
+}
+
+func (e atan2Evaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e atan2Evaluator) inverse() bool {
+	return true
+}
+
+func (e normalizeAngleEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.evaluateTurn(left, 2*math.Pi), nil
+}
+
+// evaluateTurn returns the angle within [0, fullTurn)
+func (e normalizeAngleEvaluator) evaluateTurn(angle, fullTurn float64) float64 {
+	angle = math.Mod(angle, fullTurn)
+	if angle < 0 {
+		angle += fullTurn
+	}
+	if angle == fullTurn {
+		// a tiny negative angle rounds up to a full turn
+		return 0
+	}
+	return angle
+}
+
+func (e normalizeAngleEvaluator) Supports(operator string) bool {
+	return operator == "normangle"
+}
+
+func (e normalizeAngleEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e normalizeAngleEvaluator) Type() Type {
+	return Function
+}
+
+func (e normalizeAngleEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e referenceAngleEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.evaluateTurn(left, 2*math.Pi), nil
+}
+
+// evaluateTurn returns the acute angle between the angle and the x-axis
+func (e referenceAngleEvaluator) evaluateTurn(angle, fullTurn float64) float64 {
+	angle = normalizeAngleEvaluator{}.evaluateTurn(angle, fullTurn)
+	switch {
+	case angle <= fullTurn/4:
+		return angle
+	case angle <= fullTurn/2:
+		return fullTurn/2 - angle
+	case angle <= fullTurn*3/4:
+		return angle - fullTurn/2
+	}
+	return fullTurn - angle
+}
+
+func (e referenceAngleEvaluator) Supports(operator string) bool {
+	return operator == "refangle"
+}
+
+func (e referenceAngleEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e referenceAngleEvaluator) Type() Type {
+	return Function
+}
+
+func (e referenceAngleEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e dotEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left, right})
+}
+
+// EvaluateArgs fails, as the dot product takes two vectors, which only
+// Evaluator.EvaluateVector has
+func (e dotEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	return 0, errors.New("dot requires two vectors, evaluate with EvaluateVector")
+}
+
+func (e dotEvaluator) Arity() (int, int) {
+	return 2, 2
+}
+
+func (e dotEvaluator) Supports(operator string) bool {
+	return operator == "dot"
+}
+
+func (e dotEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e dotEvaluator) Type() Type {
+	return Function
+}
+
+func (e dotEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e cross2Evaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left, right})
+}
+
+// EvaluateArgs computes the scalar cross product of two 2D vectors,
+// cross2(ax, ay, bx, by) = ax*by - ay*bx
+func (e cross2Evaluator) EvaluateArgs(args []float64) (float64, error) {
+	if len(args) != 4 {
+		return 0, errors.New("cross2 requires 4 arguments")
+	}
+	return args[0]*args[3] - args[1]*args[2], nil
+}
+
+func (e cross2Evaluator) Arity() (int, int) {
+	return 4, 4
+}
+
+func (e cross2Evaluator) Supports(operator string) bool {
+	return operator == "cross2"
+}
+
+func (e cross2Evaluator) Precedence() Precedence {
+	return High
+}
+
+func (e cross2Evaluator) Type() Type {
+	return Function
+}
+
+func (e cross2Evaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e argmaxEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left})
+}
+
+// EvaluateArgs returns the 1-based index of the largest argument,
+// the first one if several are equal
+func (e argmaxEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	index := 0
+	for i, arg := range args {
+		if arg > args[index] {
+			index = i
+		}
+	}
+	return float64(index + 1), nil
+}
+
+func (e argmaxEvaluator) Arity() (int, int) {
+	return 1, -1
+}
+
+func (e argmaxEvaluator) Supports(operator string) bool {
+	return operator == "argmax"
+}
+
+func (e argmaxEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e argmaxEvaluator) Type() Type {
+	return Function
+}
+
+func (e argmaxEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e argminEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left})
+}
+
+// EvaluateArgs returns the 1-based index of the smallest argument,
+// the first one if several are equal
+func (e argminEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	index := 0
+	for i, arg := range args {
+		if arg < args[index] {
+			index = i
+		}
+	}
+	return float64(index + 1), nil
+}
+
+func (e argminEvaluator) Arity() (int, int) {
+	return 1, -1
+}
+
+func (e argminEvaluator) Supports(operator string) bool {
+	return operator == "argmin"
+}
+
+func (e argminEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e argminEvaluator) Type() Type {
+	return Function
+}
+
+func (e argminEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e maxEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left})
+}
+
+// EvaluateArgs returns the largest argument, NaN if any is NaN
+func (e maxEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	return slices.Max(args), nil
+}
+
+func (e maxEvaluator) Arity() (int, int) {
+	return 1, -1
+}
+
+func (e maxEvaluator) Supports(operator string) bool {
+	return operator == "max"
+}
+
+func (e maxEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e maxEvaluator) Type() Type {
+	return Function
+}
+
+func (e maxEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e minEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left})
+}
+
+// EvaluateArgs returns the smallest argument, NaN if any is NaN
+func (e minEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	return slices.Min(args), nil
+}
+
+func (e minEvaluator) Arity() (int, int) {
+	return 1, -1
+}
+
+func (e minEvaluator) Supports(operator string) bool {
+	return operator == "min"
+}
+
+func (e minEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e minEvaluator) Type() Type {
+	return Function
+}
+
+func (e minEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e weightedMeanEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left, right})
+}
+
+// EvaluateArgs returns the weighted average of alternating value and weight
+// arguments, wmean(v1, w1, v2, w2, ...)
+func (e weightedMeanEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	if len(args)%2 != 0 {
+		return 0, errors.New("wmean requires value and weight pairs")
+	}
+	var sum, totalWeight float64
+	for i := 0; i < len(args); i += 2 {
+		sum += args[i] * args[i+1]
+		totalWeight += args[i+1]
+	}
+	if totalWeight == 0 {
+		return 0, errors.New("wmean total weight is zero")
+	}
+	return sum / totalWeight, nil
+}
+
+func (e weightedMeanEvaluator) Arity() (int, int) {
+	return 2, -1
+}
+
+func (e weightedMeanEvaluator) Supports(operator string) bool {
+	return operator == "wmean"
+}
+
+func (e weightedMeanEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e weightedMeanEvaluator) Type() Type {
+	return Function
+}
+
+func (e weightedMeanEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e percentOfEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left, right})
+}
+
+// EvaluateArgs returns the percentage part is of whole, pctof(part, whole)
+func (e percentOfEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	part, whole := args[0], args[1]
+	if whole == 0 {
+		return 0, errors.New("pctof of a zero whole")
+	}
+	return part / whole * 100, nil
+}
+
+func (e percentOfEvaluator) Arity() (int, int) {
+	return 2, 2
+}
+
+func (e percentOfEvaluator) Supports(operator string) bool {
+	return operator == "pctof"
+}
+
+func (e percentOfEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e percentOfEvaluator) Type() Type {
+	return Function
+}
+
+func (e percentOfEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e gcdEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left, right})
+}
+
+// EvaluateArgs returns the greatest common divisor of the integer arguments
+func (e gcdEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	var result int64
+	for _, arg := range args {
+		n, err := requireInteger("gcd", arg)
+		if err != nil {
+			return 0, err
+		}
+		if n == math.MinInt64 {
+			// its absolute value is not an int64
+			return 0, fmt.Errorf("function gcd argument %v is out of integer range", arg)
+		}
+		if n < 0 {
+			n = -n
+		}
+		for n != 0 {
+			result, n = n, result%n
+		}
+	}
+	return float64(result), nil
+}
+
+func (e gcdEvaluator) Arity() (int, int) {
+	return 2, -1
+}
+
+func (e gcdEvaluator) Supports(operator string) bool {
+	return operator == "gcd"
+}
+
+func (e gcdEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e gcdEvaluator) Type() Type {
+	return Function
+}
+
+func (e gcdEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e combinationEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left, right})
+}
+
+// EvaluateArgs returns the number of ways to choose k of n items,
+// comb(n, k) = n! / (k! * (n - k)!)
+func (e combinationEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	n, err := requireInteger("comb", args[0])
+	if err != nil {
+		return 0, err
+	}
+	k, err := requireInteger("comb", args[1])
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || k < 0 {
+		return 0, errors.New("comb requires non-negative arguments")
+	}
+	if k > n {
+		return 0, nil
+	}
+	k = min(k, n-k)
+	// after i steps the result is comb(n-k+i, i), at least comb(2i, i),
+	// which overflows before i reaches 520, bounding the loop
+	var result float64 = 1
+	for i := int64(1); i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+		if math.IsInf(result, 1) {
+			return 0, fmt.Errorf("comb(%v, %v) overflows", args[0], args[1])
+		}
+	}
+	return math.Round(result), nil
+}
+
+func (e combinationEvaluator) Arity() (int, int) {
+	return 2, 2
+}
+
+func (e combinationEvaluator) Supports(operator string) bool {
+	return operator == "comb"
+}
+
+func (e combinationEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e combinationEvaluator) Type() Type {
+	return Function
+}
+
+func (e combinationEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e binomialPMFEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs([]float64{left, right})
+}
+
+// EvaluateArgs returns the probability of k successes in n trials of
+// probability p, binompmf(k, n, p)
+func (e binomialPMFEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	k, err := requireInteger("binompmf", args[0])
+	if err != nil {
+		return 0, err
+	}
+	n, err := requireInteger("binompmf", args[1])
+	if err != nil {
+		return 0, err
+	}
+	p := args[2]
+	if n < 0 {
+		return 0, errors.New("binompmf requires a non-negative number of trials")
+	}
+	if !(p >= 0 && p <= 1) {
+		return 0, fmt.Errorf("binompmf requires a probability in [0, 1], got %v", p)
+	}
+	if k < 0 || k > n {
+		return 0, nil
+	}
+	ways, err := combinationEvaluator{}.EvaluateArgs([]float64{float64(n), float64(k)})
+	if err != nil {
+		return 0, err
+	}
+	return ways * math.Pow(p, float64(k)) * math.Pow(1-p, float64(n-k)), nil
+}
+
+func (e binomialPMFEvaluator) Arity() (int, int) {
+	return 3, 3
+}
+
+func (e binomialPMFEvaluator) Supports(operator string) bool {
+	return operator == "binompmf"
+}
+
+func (e binomialPMFEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e binomialPMFEvaluator) Type() Type {
+	return Function
+}
+
+func (e binomialPMFEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// Evaluate returns 1 if the integer operand is a prime number, otherwise 0
+func (e isPrimeEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.evaluateContext(context.Background(), []float64{left})
+}
+
+func (e isPrimeEvaluator) evaluateContext(ctx context.Context, args []float64) (float64, error) {
+	n, err := requireInteger("isprime", args[0])
+	if err != nil {
+		return 0, err
+	}
+	if n < 2 {
+		return 0, nil
+	}
+	for i := int64(2); i*i <= n; i++ {
+		if i%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return 0, err
+			}
+		}
+		if n%i == 0 {
+			return 0, nil
+		}
+	}
+	return 1, nil
+}
+
+func (e isPrimeEvaluator) Supports(operator string) bool {
+	return operator == "isprime"
+}
+
+func (e isPrimeEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e isPrimeEvaluator) Type() Type {
+	return Function
+}
+
+func (e isPrimeEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e celsiusToFahrenheitEvaluator) Evaluate(left, right float64) (float64, error) {
+	return left*9/5 + 32, nil
+}
+
+func (e celsiusToFahrenheitEvaluator) Supports(operator string) bool {
+	return operator == "c2f"
+}
+
+func (e celsiusToFahrenheitEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e celsiusToFahrenheitEvaluator) Type() Type {
+	return Function
+}
+
+func (e celsiusToFahrenheitEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e fahrenheitToCelsiusEvaluator) Evaluate(left, right float64) (float64, error) {
+	return (left - 32) * 5 / 9, nil
+}
+
+func (e fahrenheitToCelsiusEvaluator) Supports(operator string) bool {
+	return operator == "f2c"
+}
+
+func (e fahrenheitToCelsiusEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e fahrenheitToCelsiusEvaluator) Type() Type {
+	return Function
+}
+
+func (e fahrenheitToCelsiusEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e celsiusToKelvinEvaluator) Evaluate(left, right float64) (float64, error) {
+	return left + 273.15, nil
+}
+
+func (e celsiusToKelvinEvaluator) Supports(operator string) bool {
+	return operator == "c2k"
+}
+
+func (e celsiusToKelvinEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e celsiusToKelvinEvaluator) Type() Type {
+	return Function
+}
+
+func (e celsiusToKelvinEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e kelvinToCelsiusEvaluator) Evaluate(left, right float64) (float64, error) {
+	return left - 273.15, nil
+}
+
+func (e kelvinToCelsiusEvaluator) Supports(operator string) bool {
+	return operator == "k2c"
+}
+
+func (e kelvinToCelsiusEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e kelvinToCelsiusEvaluator) Type() Type {
+	return Function
+}
+
+func (e kelvinToCelsiusEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e randomEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs(nil)
+}
+
+// EvaluateArgs returns a pseudo-random number in [0, 1)
+func (e randomEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	return rand.Float64(), nil
+}
+
+func (e randomEvaluator) Arity() (int, int) {
+	return 0, 0
+}
+
+func (e randomEvaluator) Supports(operator string) bool {
+	return operator == "random"
+}
+
+func (e randomEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e randomEvaluator) Type() Type {
+	return Function
+}
+
+func (e randomEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func (e nowEvaluator) Evaluate(left, right float64) (float64, error) {
+	return e.EvaluateArgs(nil)
+}
+
+// EvaluateArgs returns the current Unix time in seconds
+func (e nowEvaluator) EvaluateArgs(args []float64) (float64, error) {
+	return e.evaluateAt(time.Now()), nil
+}
+
+func (e nowEvaluator) Arity() (int, int) {
+	return 0, 0
+}
+
+func (e nowEvaluator) Supports(operator string) bool {
+	return operator == "now"
+}
+
+func (e nowEvaluator) Precedence() Precedence {
+	return High
+}
+
+func (e nowEvaluator) Type() Type {
+	return Function
+}
+
+func (e nowEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+// evaluateAt returns the Unix time of now in seconds, with the fraction
+func (e nowEvaluator) evaluateAt(now time.Time) float64 {
+	return float64(now.Unix()) + float64(now.Nanosecond())/1e9
 }
@@ -0,0 +1,718 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterOperatorSymbols(t *testing.T) {
+	tests := []struct {
+		symbol  string
+		wantErr bool
+	}{
+		{"@", false},
+		{"avg", false},
+		{"?", true},
+		{":", true},
+		{"?:", true},
+		{",", true},
+		{"a b", true},
+		{"2x", true},
+		{"+", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			err := NewOperatorEvaluatorFactory().RegisterOperator(tt.symbol, additionEvaluator{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIntegerArguments(t *testing.T) {
+	tests := []struct {
+		expression string
+		function   string
+	}{
+		{"gcd(4.5, 2)", "gcd"},
+		{"gcd(4, 2, 0.1)", "gcd"},
+		{"comb(5.5, 2)", "comb"},
+		{"comb(5, 2.5)", "comb"},
+		{"isprime(7.5)", "isprime"},
+		{"binompmf(1.5, 4, 0.5)", "binompmf"},
+		{"binompmf(1, 4.5, 0.5)", "binompmf"},
+		{"gcd(nan, 2)", "gcd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			want := "function " + tt.function + " requires an integer argument"
+			if err == nil || err.Error() != want {
+				t.Errorf("error = %v, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestIntegerFunctions(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+		wantErr    string
+	}{
+		{"gcd(12, 18)", 6, ""},
+		{"gcd(-12, 18, 8)", 2, ""},
+		{"gcd(0, 5)", 5, ""},
+		// floating point noise is taken as an integer
+		{"gcd(0.1 * 30, 6)", 3, ""},
+		{"gcd(-9223372036854775808, 2)", 0, "out of integer range"},
+		{"comb(5, 2)", 10, ""},
+		{"comb(5, 7)", 0, ""},
+		{"comb(1000, 500)", 2.7028824094543666e+299, ""},
+		{"comb(1030, 515)", 0, "comb(1030, 515) overflows"},
+		{"comb(1e15, 2e8)", 0, "overflows"},
+		{"comb(-1, 2)", 0, "non-negative"},
+		{"isprime(97)", 1, ""},
+		{"isprime(91)", 0, ""},
+		{"isprime(1)", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateExpression(tt.expression)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %v, %v, want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// meanEvaluator is a custom operator, a @ b is the mean of a and b
+type meanEvaluator struct{}
+
+func (e meanEvaluator) Evaluate(left, right float64) (float64, error) {
+	return (left + right) / 2, nil
+}
+
+func (e meanEvaluator) Supports(operator string) bool {
+	return operator == "@"
+}
+
+func (e meanEvaluator) Precedence() Precedence {
+	return Normal
+}
+
+func (e meanEvaluator) Type() Type {
+	return Infix
+}
+
+func (e meanEvaluator) Associativity() Assoc {
+	return LeftAssoc
+}
+
+func TestRegisterOperator(t *testing.T) {
+	factory := NewOperatorEvaluatorFactory()
+	if err := factory.RegisterOperator("@", meanEvaluator{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := factory.RegisterOperator("mean", meanEvaluator{}); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEvaluator(Options{Factory: factory})
+	for expression, want := range map[string]float64{"2 @ 4": 3, "1 + 2 @ 6 * 2": 7.5, "2 mean 4": 3} {
+		got, err := e.EvaluateExpression(expression)
+		if err != nil {
+			t.Fatalf("%s: %v", expression, err)
+		}
+		if got != want {
+			t.Errorf("%s = %v, want %v", expression, got, want)
+		}
+	}
+
+	if err := factory.RegisterOperator("", meanEvaluator{}); err == nil {
+		t.Error("registered an empty symbol")
+	}
+	if err := factory.RegisterOperator("#", nil); err == nil {
+		t.Error("registered a nil evaluator")
+	}
+	for _, symbol := range []string{"@", "+", "sqrt"} {
+		if err := factory.RegisterOperator(symbol, meanEvaluator{}); err == nil {
+			t.Errorf("registered %s again", symbol)
+		}
+	}
+}
+
+func TestReplaceOperator(t *testing.T) {
+	factory := NewOperatorEvaluatorFactory()
+	if err := factory.ReplaceOperator("+", meanEvaluator{}); err != nil {
+		t.Fatal(err)
+	}
+	// also registers a new symbol
+	if err := factory.ReplaceOperator("@", meanEvaluator{}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewEvaluator(Options{Factory: factory}).EvaluateExpression("2 + 4 @ 5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 4 {
+		t.Errorf("got %v, want 4", got)
+	}
+	if err := factory.ReplaceOperator("", meanEvaluator{}); err == nil {
+		t.Error("replaced an empty symbol")
+	}
+	if err := factory.ReplaceOperator("?", meanEvaluator{}); err == nil {
+		t.Error("replaced the separator ?")
+	}
+}
+
+// expressionTest is an expression and its expected result
+type expressionTest struct {
+	expression string
+	want       float64
+}
+
+// runExpressionTests evaluates each expression with the options, the
+// results must be within 1e-12 of the expected ones or both NaN
+func runExpressionTests(t *testing.T, opts Options, tests []expressionTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(opts).EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !(got == tt.want || math.Abs(got-tt.want) <= 1e-12 || math.IsNaN(got) && math.IsNaN(tt.want)) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExponentFunctions(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"exp10(3)", 1000},
+		{"exp10(-2)", 0.01},
+		{"exp10(0)", 1},
+		{"exp2(10)", 1024},
+		{"exp2(-1)", 0.5},
+		{"exp2(0.5)", math.Sqrt2},
+		{"log10(exp10(5))", 5},
+		{"log2(exp2(7))", 7},
+	})
+}
+
+func TestCross2(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		// collinear vectors
+		{"cross2(1, 2, 2, 4)", 0},
+		{"cross2(3, 0, -1, 0)", 0},
+		// perpendicular vectors, the sign gives the turn direction
+		{"cross2(1, 0, 0, 1)", 1},
+		{"cross2(0, 1, 1, 0)", -1},
+		{"cross2(2, 0, 0, 3)", 6},
+		{"cross2(1, 2, 3, 4)", -2},
+	})
+}
+
+func TestCross2Arity(t *testing.T) {
+	for _, expression := range []string{"cross2(1, 2, 3)", "cross2(1, 2, 3, 4, 5)"} {
+		if _, err := NewEvaluator(Options{}).EvaluateExpression(expression); err == nil {
+			t.Errorf("%s: want an error", expression)
+		}
+	}
+}
+
+func TestZeroPowZero(t *testing.T) {
+	tests := []struct {
+		policy ZeroPowZeroPolicy
+		want   float64
+		err    bool
+	}{
+		{ZeroPowZeroOne, 1, false},
+		{ZeroPowZeroError, 0, true},
+		{ZeroPowZeroNaN, math.NaN(), false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.policy), func(t *testing.T) {
+			e := NewEvaluator(Options{ZeroPowZero: tt.policy})
+			got, err := e.EvaluateExpression("0^0")
+			if tt.err {
+				if err == nil {
+					t.Errorf("got %v, want an error", got)
+				}
+			} else if err != nil || !(got == tt.want || math.IsNaN(got) && math.IsNaN(tt.want)) {
+				t.Errorf("got %v, %v, want %v", got, err, tt.want)
+			}
+
+			// other powers of zero are not affected
+			got, err = e.EvaluateExpression("0^2 + 2^0")
+			if err != nil || got != 1 {
+				t.Errorf("0^2 + 2^0 got %v, %v, want 1", got, err)
+			}
+		})
+	}
+}
+
+func TestPowerSuffixes(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"5²", 25},
+		{"2³", 8},
+		{"3² + 4²", 25},
+		{"(1 + 2)²", 9},
+		{"-2²", -4},
+		{"2³²", 64},
+		{"sqrt(16)³", 64},
+	})
+}
+
+// runExpressionErrors checks that each expression fails with the options
+func runExpressionErrors(t *testing.T, opts Options, expressions ...string) {
+	t.Helper()
+	for _, expression := range expressions {
+		t.Run(expression, func(t *testing.T) {
+			got, err := NewEvaluator(opts).EvaluateExpression(expression)
+			if err == nil {
+				t.Errorf("got %v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestInverse(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"inv(4)", 0.25},
+		{"inv(-0.5)", -2},
+		{"inv(inv(3))", 3},
+	})
+	runExpressionErrors(t, Options{}, "inv(0)", "inv(1 - 1)")
+}
+
+func TestTemperatureFunctions(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"c2f(100)", 212},
+		{"c2f(-40)", -40},
+		{"f2c(32)", 0},
+		{"f2c(212)", 100},
+		{"c2k(0)", 273.15},
+		{"k2c(0)", -273.15},
+		{"f2c(c2f(37))", 37},
+		{"k2c(c2k(25))", 25},
+	})
+}
+
+func TestDoubleFactorial(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"0!!", 1},
+		{"1!!", 1},
+		{"5!!", 15},
+		{"7!!", 105},
+		{"6!!", 48},
+		{"8!!", 384},
+		// !! is one operator, not a factorial of a factorial
+		{"3!!", 3},
+		{"(3!)!", 720},
+		{"2 * 4!! + 1", 17},
+	})
+	runExpressionErrors(t, Options{}, "(-1)!!", "2.5!!", "1000!!")
+}
+
+func TestFactorialOfFraction(t *testing.T) {
+	runExpressionErrors(t, Options{}, "4.5!", "0.5!", "(1 / 3)!")
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"4!", 24},
+		{"4.0!", 24},
+	})
+	runExpressionTests(t, Options{GammaFactorial: true}, []expressionTest{
+		{"4.5!", math.Gamma(5.5)},
+		{"0.5!", math.Sqrt(math.Pi) / 2},
+		{"4!", 24},
+	})
+}
+
+// arrowEvaluator is a custom right associative operator, a -> b is a - b
+type arrowEvaluator struct {
+	assoc Assoc
+}
+
+func (e arrowEvaluator) Evaluate(left, right float64) (float64, error) {
+	return left - right, nil
+}
+
+func (e arrowEvaluator) Supports(operator string) bool {
+	return operator == "->"
+}
+
+func (e arrowEvaluator) Precedence() Precedence {
+	return Normal
+}
+
+func (e arrowEvaluator) Type() Type {
+	return Infix
+}
+
+func (e arrowEvaluator) Associativity() Assoc {
+	return e.assoc
+}
+
+func TestCustomAssociativity(t *testing.T) {
+	tests := []struct {
+		assoc      Assoc
+		expression string
+		want       float64
+	}{
+		{RightAssoc, "8 -> 4 -> 2", 6},
+		{RightAssoc, "10 -> 5 -> 3 -> 1", 7},
+		{RightAssoc, "(8 -> 4) -> 2", 2},
+		// -> binds looser than *
+		{RightAssoc, "2 * 8 -> 4 -> 2", 14},
+		{LeftAssoc, "8 -> 4 -> 2", 2},
+		{LeftAssoc, "10 -> 5 -> 3 -> 1", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			factory := NewOperatorEvaluatorFactory()
+			if err := factory.RegisterOperator("->", arrowEvaluator{tt.assoc}); err != nil {
+				t.Fatal(err)
+			}
+			got, err := NewEvaluator(Options{Factory: factory}).EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgmaxArgmin(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"argmax(3, 9, 1)", 2},
+		{"argmin(3, 9, 1)", 3},
+		{"argmax(5)", 1},
+		// ties resolve to the first occurrence
+		{"argmax(7, 2, 7)", 1},
+		{"argmin(4, 1, 1, 4)", 2},
+		{"argmax(-1, -3, -2)", 1},
+	})
+	runExpressionErrors(t, Options{}, "argmax()", "argmin()")
+}
+
+func TestWeightedMean(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"wmean(1, 1, 3, 1)", 2},
+		{"wmean(80, 0.25, 90, 0.75)", 87.5},
+		{"wmean(1, 1, 2, 2, 3, 3)", 14.0 / 6},
+		{"wmean(10, 2, 20, 0, 30, 2)", 20},
+	})
+	runExpressionErrors(t, Options{}, "wmean(1, 2, 3)", "wmean(1, 0, 2, 0)", "wmean(1, 1, 2, -1)")
+}
+
+func TestReferenceAngle(t *testing.T) {
+	// one angle in each quadrant
+	runExpressionTests(t, Options{AngleMode: Degrees}, []expressionTest{
+		{"refangle(30)", 30},
+		{"refangle(150)", 30},
+		{"refangle(210)", 30},
+		{"refangle(330)", 30},
+		{"refangle(-30)", 30},
+		{"refangle(390)", 30},
+		{"refangle(90)", 90},
+		{"refangle(180)", 0},
+		{"normangle(-90)", 270},
+		{"normangle(720)", 0},
+	})
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"refangle(pi / 6)", math.Pi / 6},
+		{"refangle(2 * pi / 3)", math.Pi / 3},
+		{"refangle(5 * pi / 4)", math.Pi / 4},
+		{"refangle(-pi / 3)", math.Pi / 3},
+		{"normangle(-pi / 2)", 3 * math.Pi / 2},
+	})
+	runExpressionTests(t, Options{AngleMode: Gradians}, []expressionTest{
+		{"refangle(150)", 50},
+		{"refangle(250)", 50},
+		{"refangle(350)", 50},
+		{"normangle(-100)", 300},
+	})
+}
+
+func TestBinomialPMF(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"binompmf(2, 5, 0.5)", 0.3125},
+		{"binompmf(0, 5, 0.5)", 0.03125},
+		{"binompmf(3, 10, 0.2)", 0.201326592},
+		{"binompmf(0, 3, 0)", 1},
+		{"binompmf(3, 3, 1)", 1},
+		// outside 0..n the probability is 0
+		{"binompmf(6, 5, 0.5)", 0},
+		{"binompmf(-1, 5, 0.5)", 0},
+	})
+	runExpressionErrors(t, Options{},
+		"binompmf(2, 5, 1.5)",
+		"binompmf(2, 5, -0.1)",
+		"binompmf(2.5, 5, 0.5)",
+		"binompmf(2, 5.5, 0.5)",
+	)
+}
+
+func TestMinMax(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"max(3, 7, 2)", 7},
+		{"min(-1, 4)", -1},
+		{"max(5)", 5},
+		{"min(5)", 5},
+		{"max(1, 2) + min(3, 4)", 5},
+		{"max(min(1, 2), min(3, 4), -max(5, 6))", 3},
+	})
+	runExpressionErrors(t, Options{}, "max()", "min()")
+}
+
+func TestFactorial(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"5!", 120},
+		{"0!", 1},
+		{"1!", 1},
+		{"3! + 1", 7},
+		{"-3!", -6},
+		{"20!", 2432902008176640000},
+	})
+	runExpressionErrors(t, Options{}, "(-1)!", "(-3)!", "2.5!", "171!")
+}
+
+func TestFactorialOverflow(t *testing.T) {
+	got, err := NewEvaluator(Options{}).EvaluateExpression("170!")
+	if err != nil || math.IsInf(got, 0) || got < 7.2e306 {
+		t.Errorf("170! got %v, %v", got, err)
+	}
+	for _, expression := range []string{"171!", "200!", "1e9!"} {
+		_, err := NewEvaluator(Options{}).EvaluateExpression(expression)
+		if err == nil || !strings.Contains(err.Error(), "overflow") {
+			t.Errorf("%s: got error %v, want an overflow", expression, err)
+		}
+	}
+}
+
+func TestRemainder(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"7.5 % 2", 1.5},
+	})
+	for _, expression := range []string{"7 % 0", "7 % (1 - 1)"} {
+		_, err := NewEvaluator(Options{}).EvaluateExpression(expression)
+		if err == nil || err.Error() != "modulo by zero" {
+			t.Errorf("%s: got error %v, want modulo by zero", expression, err)
+		}
+	}
+}
+
+func TestLogarithm(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		// a single argument stays the natural logarithm
+		{"log(e)", 1},
+		{"log(1)", 0},
+		{"log(2, 8)", 3},
+		{"log(10, 1000)", 3},
+		{"log(3, 81)", 4},
+		{"log(2, 0.125)", -3},
+		{"log(0.5, 0.25)", 2},
+		{"log(2, 0)", math.Inf(-1)},
+		{"log(2, -8)", math.NaN()},
+		{"log10(1000)", 3},
+		{"log10(0.001)", -3},
+		{"log10(0)", math.Inf(-1)},
+		{"log2(1024)", 10},
+		{"log2(0.5)", -1},
+		{"log2(-1)", math.NaN()},
+	})
+	for _, expression := range []string{"log(1, 5)", "log(0, 5)", "log(-2, 8)"} {
+		_, err := NewEvaluator(Options{}).EvaluateExpression(expression)
+		if err == nil || !strings.Contains(err.Error(), "invalid base") {
+			t.Errorf("%s: got error %v, want an invalid base", expression, err)
+		}
+	}
+	runExpressionErrors(t, Options{}, "log()", "log(2, 8, 1)", "log10(1, 2)", "log2()")
+}
+
+func TestLogarithmBase(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"logb(8, 2)", 3},
+		{"logb(1000, 10)", 3},
+		{"logb(0.25, 0.5)", 2},
+	})
+	for _, expression := range []string{"logb(8, 1)", "logb(8, -2)", "logb(8, 0)"} {
+		_, err := NewEvaluator(Options{}).EvaluateExpression(expression)
+		if err == nil || !strings.Contains(err.Error(), "invalid base") {
+			t.Errorf("%s: got error %v, want an invalid base", expression, err)
+		}
+	}
+}
+
+func TestZeroArgumentCall(t *testing.T) {
+	e := NewEvaluator(Options{})
+	for i := 0; i < 100; i++ {
+		got, err := e.EvaluateExpression("2 * random() + 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got < 1 || got >= 3 {
+			t.Fatalf("got %v, want a value in [1, 3)", got)
+		}
+	}
+	got, err := e.EvaluateScript("two() = 2; two() * 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 6 {
+		t.Errorf("got %v, want 6", got)
+	}
+	for _, expression := range []string{"random(1)", "random(1, 2)", "two(1)", "sqrt()", "max()", "random"} {
+		t.Run(expression, func(t *testing.T) {
+			if got, err := e.EvaluateExpression(expression); err == nil {
+				t.Errorf("got %v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestNow(t *testing.T) {
+	clock := time.Unix(1700000000, 500000000)
+	e := NewEvaluator(Options{Clock: func() time.Time { return clock }})
+	runNow := func(expression string, want float64) {
+		t.Helper()
+		got, err := e.EvaluateExpression(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s got %v, want %v", expression, got, want)
+		}
+	}
+	runNow("now()", 1700000000.5)
+	runNow("now() - 0.5", 1700000000)
+	runNow("floor(now() / 86400)", 19675)
+
+	// the clock is read on every evaluation
+	clock = clock.Add(time.Minute)
+	runNow("now()", 1700000060.5)
+
+	if _, err := e.EvaluateExpression("now(1)"); err == nil {
+		t.Error("now(1) got no error")
+	}
+}
+
+func TestBitwiseOperators(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"6 & 3", 2},
+		{"6 | 3", 7},
+		{"6 xor 3", 5},
+		{"1 << 4", 16},
+		{"256 >> 4", 16},
+		{"-1 & 255", 255},
+		// below arithmetic
+		{"1 + 2 & 3", 3},
+		{"1 << 2 + 1", 8},
+	})
+	runExpressionErrors(t, Options{}, "1.5 & 2", "2 | 1.5", "3 xor 0.1", "1 << 0.5", "1 << -1")
+}
+
+func TestActivationFunctions(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"sigmoid(0)", 0.5},
+		{"sigmoid(2)", 1 / (1 + math.Exp(-2))},
+		{"sigmoid(-2)", 1 / (1 + math.Exp(2))},
+		// stable at extremes where exp overflows
+		{"sigmoid(1000)", 1},
+		{"sigmoid(-1000)", 0},
+		{"relu(-1)", 0},
+		{"relu(0)", 0},
+		{"relu(2.5)", 2.5},
+		{"softplus(0)", math.Ln2},
+		{"softplus(1)", math.Log1p(math.E)},
+		{"softplus(-1)", math.Log1p(1 / math.E)},
+		{"softplus(1000)", 1000},
+		{"softplus(-1000)", 0},
+	})
+}
+
+func TestRoundingFunctions(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"abs(-3)", 3},
+		{"abs(3)", 3},
+		{"abs(-0.5)", 0.5},
+		{"floor(2.7)", 2},
+		{"floor(-2.1)", -3},
+		{"ceil(2.1)", 3},
+		{"ceil(-2.7)", -2},
+		{"round(2.5)", 3},
+		{"round(-2.5)", -3},
+		{"round(2.4)", 2},
+		{"abs(floor(-1.5))", 2},
+	})
+	runExpressionErrors(t, Options{}, "abs()", "floor(1, 2)")
+}
+
+func TestCaretXor(t *testing.T) {
+	runExpressionTests(t, Options{CaretXor: true}, []expressionTest{
+		{"5 ^ 1", 4},
+		{"2 ^ 10", 8},
+		{"2 ** 10", 1024},
+		// like xor, below arithmetic
+		{"6 ^ 3 + 1", 2},
+	})
+	runExpressionErrors(t, Options{CaretXor: true}, "1.5 ^ 1")
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"2 ^ 10", 1024},
+		{"2 ** 10", 1024},
+		{"5 ^ 1", 5},
+	})
+}
+
+func TestPctOf(t *testing.T) {
+	runExpressionTests(t, Options{}, []expressionTest{
+		{"pctof(25, 200)", 12.5},
+		{"pctof(200, 200)", 100},
+		{"pctof(0, 5)", 0},
+		{"pctof(-1, 4)", -25},
+		{"pctof(3, 2)", 150},
+	})
+	runExpressionErrors(t, Options{}, "pctof(1, 0)", "pctof(0, 0)", "pctof(1)")
+}
@@ -0,0 +1,95 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"io"
+	"time"
+)
+
+// Options configures an Evaluator created by NewEvaluator,
+// see the Evaluator fields of the same names.
+type Options struct {
+	// Factory provides the operators and functions,
+	// defaults to NewOperatorEvaluatorFactory
+	Factory OperatorEvaluatorFactory
+
+	AngleMode                AngleMode
+	CaseInsensitiveFunctions bool
+	NoPrecedence             bool
+	InputBase                int
+	GammaFactorial           bool
+	ZeroPowZero              ZeroPowZeroPolicy
+	NaNPolicy                NaNPolicy
+	DomainErrors             bool
+	MaxMagnitude             float64
+	UnescapeEntities         bool
+	RoundOperation           func(float64) float64
+	Precision                int
+	StrictParentheses        bool
+	MaxCallDepth             int
+	FortranExponent          bool
+	CaretXor                 bool
+	Clock                    func() time.Time
+	Trace                    io.Writer
+
+	// NumberParser replaces the parser of number literals,
+	// see Evaluator.SetNumberParser
+	NumberParser func(string) (float64, error)
+
+	// AuditHook receives every operation, see Evaluator.SetAuditHook
+	AuditHook func(op string, operands []float64, result float64)
+}
+
+// NewEvaluator creates an Evaluator configured by the options.
+func NewEvaluator(opts Options) *Evaluator {
+	factory := opts.Factory
+	if factory == nil {
+		factory = NewOperatorEvaluatorFactory()
+	}
+	return &Evaluator{
+		OperatorEvaluatorFactory: factory,
+		AngleMode:                opts.AngleMode,
+		CaseInsensitiveFunctions: opts.CaseInsensitiveFunctions,
+		NoPrecedence:             opts.NoPrecedence,
+		InputBase:                opts.InputBase,
+		GammaFactorial:           opts.GammaFactorial,
+		ZeroPowZero:              opts.ZeroPowZero,
+		NaNPolicy:                opts.NaNPolicy,
+		DomainErrors:             opts.DomainErrors,
+		MaxMagnitude:             opts.MaxMagnitude,
+		UnescapeEntities:         opts.UnescapeEntities,
+		RoundOperation:           opts.RoundOperation,
+		Precision:                opts.Precision,
+		StrictParentheses:        opts.StrictParentheses,
+		MaxCallDepth:             opts.MaxCallDepth,
+		FortranExponent:          opts.FortranExponent,
+		CaretXor:                 opts.CaretXor,
+		Clock:                    opts.Clock,
+		Trace:                    opts.Trace,
+		numberParser:             opts.NumberParser,
+		auditHook:                opts.AuditHook,
+	}
+}
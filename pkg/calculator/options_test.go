@@ -0,0 +1,146 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewEvaluatorOptions(t *testing.T) {
+	clock := time.Unix(1700000000, 0)
+	doubling := func(s string) (float64, error) {
+		v, err := strconv.ParseFloat(s, 64)
+		return v * 2, err
+	}
+
+	// failed marks an evaluation expected to return an error
+	failed := math.Inf(-1)
+	tests := []struct {
+		name       string
+		opts       Options
+		setup      string
+		expression string
+		// without is the result without the option
+		without float64
+		want    float64
+	}{
+		{"AngleMode", Options{AngleMode: Degrees}, "", "sin(90)", math.Sin(90), 1},
+		{"CaseInsensitiveFunctions", Options{CaseInsensitiveFunctions: true}, "", "SQRT(4)", failed, 2},
+		{"NoPrecedence", Options{NoPrecedence: true}, "", "2 + 3 * 4", 14, 20},
+		{"InputBase", Options{InputBase: 16}, "", "10 + 1", 11, 17},
+		{"GammaFactorial", Options{GammaFactorial: true}, "", "0.5!", failed, math.Gamma(1.5)},
+		{"ZeroPowZero", Options{ZeroPowZero: ZeroPowZeroError}, "", "0 ^ 0", 1, failed},
+		{"NaNPolicy", Options{NaNPolicy: NaNError}, "", "nan + 1", math.NaN(), failed},
+		{"DomainErrors", Options{DomainErrors: true}, "", "asin(2)", math.NaN(), failed},
+		{"MaxMagnitude", Options{MaxMagnitude: 100}, "", "10 * 11", 110, failed},
+		{"UnescapeEntities", Options{UnescapeEntities: true}, "", "6 &amp; 3", failed, 2},
+		{"RoundOperation", Options{RoundOperation: math.Round}, "", "1.4 * 1.4 * 1.4", 1.4 * 1.4 * 1.4, 3},
+		{"Precision", Options{Precision: 2}, "", "0.1 + 0.2", 0.1 + 0.2, 0.3},
+		{"StrictParentheses", Options{StrictParentheses: true}, "", "sqrt 4", 2, failed},
+		{"MaxCallDepth", Options{MaxCallDepth: 1}, "f(x) = x + 1; g(x) = f(x) * 2; 0", "g(1)", 4, failed},
+		{"FortranExponent", Options{FortranExponent: true}, "", "1.5D3", failed, 1500},
+		{"CaretXor", Options{CaretXor: true}, "", "5 ^ 1", 5, 4},
+		{"NumberParser", Options{NumberParser: doubling}, "", "1 + 1", 2, 4},
+		{"Clock", Options{Clock: func() time.Time { return clock }}, "", "now()", float64(time.Now().Unix()), 1700000000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, withOption := range []bool{false, true} {
+				opts, want := Options{}, tt.without
+				if withOption {
+					opts, want = tt.opts, tt.want
+				}
+				e := NewEvaluator(opts)
+				if tt.setup != "" {
+					if _, err := e.EvaluateScript(tt.setup); err != nil {
+						t.Fatal(err)
+					}
+				}
+				got, err := e.EvaluateExpression(tt.expression)
+				if want == failed {
+					if err == nil {
+						t.Errorf("option %v: got %v, want an error", withOption, got)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("option %v: %v", withOption, err)
+				}
+				// the real clock only needs to be close
+				tol := 1e-12
+				if tt.name == "Clock" && !withOption {
+					tol = 60
+				}
+				if !(math.Abs(got-want) <= tol || math.IsNaN(got) && math.IsNaN(want)) {
+					t.Errorf("option %v: got %v, want %v", withOption, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewEvaluatorHooks(t *testing.T) {
+	factory := NewOperatorEvaluatorFactory()
+	trace := strings.Builder{}
+	var audited []string
+	e := NewEvaluator(Options{
+		Factory:   factory,
+		Trace:     &trace,
+		AuditHook: func(op string, operands []float64, result float64) { audited = append(audited, op) },
+	})
+	if e.OperatorEvaluatorFactory != factory {
+		t.Error("Factory is not used")
+	}
+	if _, err := e.EvaluateExpression("1 + 2 * 3"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(trace.String(), "reverse polish notation") {
+		t.Errorf("Trace got %q", trace.String())
+	}
+	if strings.Join(audited, " ") != "* +" {
+		t.Errorf("AuditHook got %v, want [* +]", audited)
+	}
+}
+
+func TestZeroValueEvaluator(t *testing.T) {
+	var e Evaluator
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := e.EvaluateExpression("sqrt(16) + 1")
+			if err != nil || got != 5 {
+				t.Errorf("got %v, %v, want 5", got, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,236 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// maxRationalBits bounds the size of the numerator or denominator of a
+// power, so that e.g. 10^10^9 fails instead of exhausting memory
+const maxRationalBits = 1 << 22
+
+// EvaluateRational evaluates the expression exactly with rational numbers
+// rather than float64, e.g. 0.1 + 0.2 is exactly 3/10 and 1e30 + 1 keeps
+// the 1. Number literals, including scientific notation like 2.5e-3, are
+// read exactly without going through float64.
+//
+// Only + - * / % ^ ** ² ³, unary signs, percentages, comparisons and
+// conditionals are supported, with their built-in meaning. Functions,
+// variables and constants like pi, which have no exact rational value,
+// are errors. ^ takes whole exponents only. With CaretXor ^ is the
+// bitwise xor, which is not supported, ** remains the power.
+func (e *Evaluator) EvaluateRational(expression string) (*big.Rat, error) {
+	e.setWarnings(nil)
+	if !e.isDecimalInput() {
+		return nil, fmt.Errorf("rational mode needs decimal input, not base %d", e.InputBase)
+	}
+	tokens, err := e.tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("no tokens found")
+	}
+	polishNotation, err := e.toReversePolishNotation(tokens)
+	if err != nil {
+		return nil, err
+	}
+	return e.runRational(polishNotation)
+}
+
+func (e *Evaluator) runRational(polishNotation []Token) (*big.Rat, error) {
+	var stack []*big.Rat
+	for i := 0; i < len(polishNotation); i++ {
+		t := polishNotation[i]
+		switch t.Type {
+		case Number:
+			num, err := parseRational(t.Value)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, num)
+		case Variable:
+			if _, ok := e.constant(t.Value); ok {
+				return nil, fmt.Errorf("constant %s is not rational", t.Value)
+			}
+			return nil, fmt.Errorf("undefined variable: %s", t.Value)
+		case Operator:
+			if t.Value == "^" && e.CaretXor {
+				return nil, fmt.Errorf("^ is xor with CaretXor, which is not supported in rational mode")
+			}
+			n := 1
+			if _, infix := rationalInfix[t.Value]; infix {
+				n = 2
+			}
+			if len(stack) < n {
+				return nil, fmt.Errorf("invalid expression")
+			}
+			operands := stack[len(stack)-n:]
+			stack = stack[:len(stack)-n]
+			result, err := applyRational(t.Value, operands)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, result)
+		case Question:
+			if len(stack) < 1 {
+				return nil, fmt.Errorf("invalid expression")
+			}
+			condition := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if condition.Sign() == 0 {
+				i = t.target - 1
+			}
+		case Colon:
+			i = t.target - 1
+		}
+	}
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("invalid expression")
+	}
+	return stack[0], nil
+}
+
+// rationalInfix lists the infix operators of the rational mode
+var rationalInfix = map[string]struct{}{
+	"+": {}, "-": {}, "*": {}, "/": {}, "%": {}, "^": {}, "**": {},
+	"<": {}, ">": {}, "<=": {}, ">=": {}, "==": {}, "!=": {},
+}
+
+// parseRational reads a number literal exactly, e.g. 1e3 as 1000 and 0.1
+// as 1/10 rather than the closest float64
+func parseRational(input string) (*big.Rat, error) {
+	if hasRadixPrefix(input) {
+		base := radixPrefixes[input[1]|0x20]
+		num, ok := new(big.Int).SetString(input[2:], base)
+		if !ok {
+			return nil, fmt.Errorf("invalid number %s in base %d", input, base)
+		}
+		return new(big.Rat).SetInt(num), nil
+	}
+	num, ok := new(big.Rat).SetString(input)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %s", input)
+	}
+	return num, nil
+}
+
+func applyRational(op string, operands []*big.Rat) (*big.Rat, error) {
+	result := new(big.Rat)
+	switch op {
+	case "neg":
+		return result.Neg(operands[0]), nil
+	case "percent":
+		return result.Quo(operands[0], big.NewRat(100, 1)), nil
+	case "²":
+		return result.Mul(operands[0], operands[0]), nil
+	case "³":
+		result.Mul(operands[0], operands[0])
+		return result.Mul(result, operands[0]), nil
+	}
+	if _, infix := rationalInfix[op]; !infix {
+		return nil, fmt.Errorf("%s is not supported in rational mode", op)
+	}
+
+	left, right := operands[0], operands[1]
+	switch op {
+	case "+":
+		return result.Add(left, right), nil
+	case "-":
+		return result.Sub(left, right), nil
+	case "*":
+		return result.Mul(left, right), nil
+	case "/":
+		if right.Sign() == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return result.Quo(left, right), nil
+	case "%":
+		if right.Sign() == 0 {
+			return nil, errors.New("modulo by zero")
+		}
+		// the remainder has the sign of the dividend, as math.Mod
+		quotient := new(big.Rat).Quo(left, right)
+		whole := new(big.Int).Quo(quotient.Num(), quotient.Denom())
+		result.SetInt(whole)
+		return result.Sub(left, result.Mul(result, right)), nil
+	case "^", "**":
+		return powRational(left, right)
+	}
+	return compareRational(op, left.Cmp(right)), nil
+}
+
+// compareRational returns 1 if cmp, the comparison of the operands,
+// satisfies op and 0 otherwise
+func compareRational(op string, cmp int) *big.Rat {
+	var b bool
+	switch op {
+	case "<":
+		b = cmp < 0
+	case ">":
+		b = cmp > 0
+	case "<=":
+		b = cmp <= 0
+	case ">=":
+		b = cmp >= 0
+	case "==":
+		b = cmp == 0
+	case "!=":
+		b = cmp != 0
+	}
+	return big.NewRat(int64(truth(b)), 1)
+}
+
+// powRational raises base to a whole exponent, negative exponents giving
+// the reciprocal
+func powRational(base, exponent *big.Rat) (*big.Rat, error) {
+	if !exponent.IsInt() {
+		return nil, fmt.Errorf("exponent %s is not a whole number", exponent.RatString())
+	}
+	if !exponent.Num().IsInt64() {
+		return nil, fmt.Errorf("exponent %s is too large", exponent.RatString())
+	}
+	n := exponent.Num().Int64()
+	if n < 0 && base.Sign() == 0 {
+		return nil, errors.New("division by zero")
+	}
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+	bits := max(base.Num().BitLen(), base.Denom().BitLen())
+	if bits > 1 && abs > maxRationalBits/int64(bits-1) {
+		return nil, fmt.Errorf("%s ^ %d is too large", base.RatString(), n)
+	}
+	num := new(big.Int).Exp(base.Num(), big.NewInt(abs), nil)
+	denom := new(big.Int).Exp(base.Denom(), big.NewInt(abs), nil)
+	if n < 0 {
+		num, denom = denom, num
+	}
+	return new(big.Rat).SetFrac(num, denom), nil
+}
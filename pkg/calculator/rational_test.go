@@ -0,0 +1,118 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestEvaluateRational(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{"1e3 + 1", "1001"},
+		{"1e30 + 1", "1000000000000000000000000000001"},
+		{"2.5e-3 * 4", "1/100"},
+		{"1E3", "1000"},
+		{"0.1 + 0.2", "3/10"},
+		{"1 / 3 * 3", "1"},
+		{"0xff + 0b1", "256"},
+		{"2 ^ -3", "1/8"},
+		{"(-2) ^ 3", "-8"},
+		{"-2 ^ 2", "-4"},
+		{"3²", "9"},
+		{"7 % -3", "1"},
+		{"-7 % 3", "-1"},
+		{"7.5 % 2", "3/2"},
+		{"50%", "1/2"},
+		{"1 / 3 == 2 / 6", "1"},
+		{"0 ? 1 / 0 : 2", "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateRational(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := new(big.Rat).SetString(tt.want)
+			if got.Cmp(want) != 0 {
+				t.Errorf("got %s, want %s", got.RatString(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateRationalFloatDiffers(t *testing.T) {
+	// float64 cannot hold 1e30 + 1, the rational mode keeps the 1
+	got, err := NewEvaluator(Options{}).EvaluateRational("1e30 + 1 - 1e30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(big.NewRat(1, 1)) != 0 {
+		t.Errorf("got %s, want 1", got.RatString())
+	}
+}
+
+func TestEvaluateRationalErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		err        string
+	}{
+		{"1 / 0", "division by zero"},
+		{"1 % 0", "modulo by zero"},
+		{"0 ^ -1", "division by zero"},
+		{"2 ^ 0.5", "not a whole number"},
+		{"10 ^ 10 ^ 9", "too large"},
+		{"sqrt(4)", "not supported"},
+		{"pi", "not rational"},
+		{"x + 1", "undefined variable"},
+		{"1 +", "cannot end with an operator"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := NewEvaluator(Options{}).EvaluateRational(tt.expression)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEvaluateRationalCaretXor(t *testing.T) {
+	e := NewEvaluator(Options{CaretXor: true})
+	if got, err := e.EvaluateRational("6 ^ 3"); err == nil || !strings.Contains(err.Error(), "xor") {
+		t.Errorf("6 ^ 3 got %v, %v, want a xor error", got, err)
+	}
+	got, err := e.EvaluateRational("6 ** 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(big.NewRat(216, 1)) != 0 {
+		t.Errorf("6 ** 3 got %s, want 216", got.RatString())
+	}
+}
@@ -0,0 +1,239 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// defaultMaxCallDepth limits the nesting of user defined function calls
+// unless Evaluator.MaxCallDepth is set
+const defaultMaxCallDepth = 100
+
+var (
+	// definitionPattern is f(params) = body, not a comparison like f(1) == 2
+	definitionPattern = regexp.MustCompile(`^\s*([A-Za-z][A-Za-z0-9_]*)\s*\(([^()]*)\)\s*=((?:[^=].*)?)$`)
+	namePattern       = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	// templateNamePattern is a function name with optional parameters
+	templateNamePattern = regexp.MustCompile(`^\s*([A-Za-z][A-Za-z0-9_]*)\s*(?:\(([^()]*)\))?\s*$`)
+)
+
+// EvaluateScript evaluates statements separated by semicolons, returning
+// the value of the last expression. A statement may define a function
+// instead, like f(x) = x^2 + 1 or hyp(a, b) = sqrt(a^2 + b^2), which can
+// be called by the following statements, and later evaluations, as
+// any other function.
+func (e *Evaluator) EvaluateScript(script string) (float64, error) {
+	e.setWarnings(nil)
+	var result float64
+	evaluated := false
+	for _, statement := range strings.Split(script, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if match := definitionPattern.FindStringSubmatch(statement); match != nil {
+			err := e.defineFunction(match[1], match[2], match[3])
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+		res, err := e.EvaluateExpression(statement)
+		if err != nil {
+			return 0, err
+		}
+		result = res
+		evaluated = true
+	}
+	if !evaluated {
+		return 0, errors.New("script has no expression to evaluate")
+	}
+	return result, nil
+}
+
+// DefineFunctions defines functions from a map of names to expression
+// templates, e.g. loaded from a configuration file:
+//
+//	{"sq": "x*x", "hyp(a, b)": "sqrt(sq(a) + sq(b))"}
+//
+// A bare name takes the only variable of its template as parameter, the
+// parameters of a template with several variables must be declared. The
+// definitions may use each other regardless of the map order.
+func (e *Evaluator) DefineFunctions(definitions map[string]string) error {
+	pending := slices.Sorted(maps.Keys(definitions))
+	for len(pending) > 0 {
+		var failed []string
+		var firstErr error
+		for _, name := range pending {
+			err := e.defineTemplate(name, definitions[name])
+			if err != nil {
+				failed = append(failed, name)
+				firstErr = cmp.Or(firstErr, err)
+			}
+		}
+		if len(failed) == len(pending) {
+			// none of them depends on a function defined in this pass
+			return firstErr
+		}
+		pending = failed
+	}
+	return nil
+}
+
+func (e *Evaluator) defineTemplate(name string, template string) error {
+	match := templateNamePattern.FindStringSubmatch(name)
+	if match == nil {
+		return fmt.Errorf("invalid function name %q", name)
+	}
+	if strings.Contains(name, "(") {
+		return e.defineFunction(match[1], match[2], template)
+	}
+	tokens, err := e.tokenize(template)
+	if err != nil {
+		return fmt.Errorf("function %s: %w", match[1], err)
+	}
+	var params []string
+	for _, t := range tokens {
+		if _, ok := e.constant(t.Value); t.Type == Variable && !ok &&
+			!slices.Contains(params, t.Value) {
+			params = append(params, t.Value)
+		}
+	}
+	if len(params) > 1 {
+		return fmt.Errorf("function %s uses the variables %s, declare them like %s(%s)",
+			match[1], strings.Join(params, ", "), match[1], strings.Join(params, ", "))
+	}
+	return e.defineFunction(match[1], strings.Join(params, ""), template)
+}
+
+func (e *Evaluator) defineFunction(name string, params string, body string) error {
+	if e.factory().IsValid(name) {
+		return fmt.Errorf("cannot redefine built-in function %s", name)
+	}
+	var paramNames []string
+	if strings.TrimSpace(params) != "" {
+		for _, param := range strings.Split(params, ",") {
+			param = strings.TrimSpace(param)
+			if !namePattern.MatchString(param) {
+				return fmt.Errorf("invalid parameter %q of function %s", param, name)
+			}
+			paramNames = append(paramNames, param)
+		}
+	}
+
+	function := &userFunction{
+		evaluator: e,
+		name:      name,
+		params:    paramNames,
+	}
+	if e.functions == nil {
+		e.functions = make(map[string]*userFunction)
+	}
+	// defined before compiling the body so the function can call itself
+	previous, redefined := e.functions[name]
+	e.functions[name] = function
+	compiled, err := e.Compile(body)
+	if err != nil {
+		if redefined {
+			e.functions[name] = previous
+		} else {
+			delete(e.functions, name)
+		}
+		return fmt.Errorf("function %s: %w", name, err)
+	}
+	function.body = compiled.polishNotation
+	function.operators = compiled.operators
+	return nil
+}
+
+// userFunction is a function defined by an expression over its parameters
+type userFunction struct {
+	evaluator *Evaluator
+	name      string
+	params    []string
+	body      []Token
+	operators []OperatorEvaluator
+}
+
+func (f *userFunction) Evaluate(left, right float64) (float64, error) {
+	return f.EvaluateArgs([]float64{left, right})
+}
+
+func (e *Evaluator) maxCallDepth() int {
+	if e.MaxCallDepth > 0 {
+		return e.MaxCallDepth
+	}
+	return defaultMaxCallDepth
+}
+
+func (f *userFunction) EvaluateArgs(args []float64) (float64, error) {
+	return f.call(&evaluation{ctx: context.Background()}, args)
+}
+
+// call evaluates the body of the function one call deeper in ev
+func (f *userFunction) call(ev *evaluation, args []float64) (float64, error) {
+	if len(args) != len(f.params) {
+		return 0, fmt.Errorf("function %s expects %d argument(s), got %d",
+			f.name, len(f.params), len(args))
+	}
+	e := f.evaluator
+	if maxDepth := e.maxCallDepth(); ev.depth >= maxDepth {
+		return 0, fmt.Errorf("maximum call depth %d exceeded in function %s", maxDepth, f.name)
+	}
+	vars := make(map[string]float64, len(f.params))
+	for i, param := range f.params {
+		vars[param] = args[i]
+	}
+	ev.depth++
+	defer func() { ev.depth-- }()
+	return e.run(ev, f.body, f.operators, vars)
+}
+
+func (f *userFunction) Arity() (int, int) {
+	return len(f.params), len(f.params)
+}
+
+func (f *userFunction) Supports(operator string) bool {
+	return operator == f.name
+}
+
+func (f *userFunction) Precedence() Precedence {
+	return High
+}
+
+func (f *userFunction) Type() Type {
+	return Function
+}
+
+func (f *userFunction) Associativity() Assoc {
+	return LeftAssoc
+}
@@ -0,0 +1,172 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScriptComparison(t *testing.T) {
+	got, err := NewEvaluator(Options{}).EvaluateScript("f(x) = x * 2; f(1) == 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1 {
+		t.Errorf("got %v, want 1", got)
+	}
+}
+
+func TestEvaluateScript(t *testing.T) {
+	tests := []struct {
+		script string
+		want   float64
+	}{
+		{"f(x) = x^2 + 1; f(3)", 10},
+		{"hyp(a, b) = sqrt(a^2 + b^2); hyp(3, 4)", 5},
+		{"g(a, b, c) = a * b + c; g(2, 3, 4)", 10},
+		{"f(x) = x + 1; g(x) = f(x) * 2; g(1)", 4},
+		{"fact(n) = n < 1 ? 1 : n * fact(n - 1); fact(5)", 120},
+		{"1 + 1; 2 * 3", 6},
+		{"f(x) = x; ; f(7);", 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateScript(tt.script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateScriptErrors(t *testing.T) {
+	tests := []struct {
+		script string
+		err    string
+	}{
+		{"f(x) = f(x) + 1; f(1)", "maximum call depth 100 exceeded in function f"},
+		{"f(x) = x; f(1, 2)", "expects 1 argument(s), got 2"},
+		{"f(x) = y; f(1)", "undefined variable: y"},
+		{"sqrt(x) = 1; 2", "cannot redefine built-in function sqrt"},
+		{"f(x) = x", "no expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			_, err := NewEvaluator(Options{}).EvaluateScript(tt.script)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestUserFunctionsPersist(t *testing.T) {
+	e := NewEvaluator(Options{})
+	if _, err := e.EvaluateScript("sq(x) = x * x; 0"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := e.EvaluateExpression("sq(3) + 1")
+	if err != nil || got != 10 {
+		t.Errorf("got %v, %v, want 10", got, err)
+	}
+}
+
+func TestDefineFunctions(t *testing.T) {
+	e := NewEvaluator(Options{})
+	err := e.DefineFunctions(map[string]string{
+		"sq":        "x*x",
+		"hyp(a, b)": "sqrt(sq(a) + sq(b))",
+		"double":    "2 * n",
+		"seven":     "7",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"sq(3)", 9},
+		{"hyp(3, 4)", 5},
+		{"double(sq(2)) + 1", 9},
+		{"seven()", 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := e.EvaluateExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefineFunctionsErrors(t *testing.T) {
+	tests := []map[string]string{
+		// several variables need declared parameters
+		{"f": "x + y"},
+		{"sqrt": "x"},
+		{"f(x)": "x +"},
+		{"2f": "x"},
+	}
+	for _, definitions := range tests {
+		if err := NewEvaluator(Options{}).DefineFunctions(definitions); err == nil {
+			t.Errorf("%v: want an error", definitions)
+		}
+	}
+}
+
+func TestMaxCallDepth(t *testing.T) {
+	tests := []struct {
+		depth  int
+		script string
+		err    string
+	}{
+		{0, "f(x) = f(x); f(1)", "maximum call depth 100 exceeded in function f"},
+		{5, "f(x) = f(x); f(1)", "maximum call depth 5 exceeded in function f"},
+		{5, "f(n) = n < 1 ? 0 : f(n - 1); f(10)", "maximum call depth 5 exceeded in function f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			_, err := NewEvaluator(Options{MaxCallDepth: tt.depth}).EvaluateScript(tt.script)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+
+	// recursion within the limit works
+	got, err := NewEvaluator(Options{MaxCallDepth: 20}).EvaluateScript("f(n) = n < 1 ? 0 : 1 + f(n - 1); f(10)")
+	if err != nil || got != 10 {
+		t.Errorf("got %v, %v, want 10", got, err)
+	}
+}
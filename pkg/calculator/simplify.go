@@ -0,0 +1,295 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// atomPrecedence is the precedence of a number, name or function call,
+// which never needs parentheses
+const atomPrecedence = High + 1
+
+// conditionalPrecedence is the precedence of cond ? a : b, which binds
+// looser than any operator
+const conditionalPrecedence = Comparison - 1
+
+// pendingConditional is a conditional with an unknown condition whose
+// branches are being simplified
+type pendingConditional struct {
+	condition partial
+	// question is the index of the ? in the reverse polish notation
+	question int
+	// end is the index after the second branch, -1 until it is known
+	end int
+}
+
+// partial is an operand of a partially evaluated expression, either a
+// known value or the text of a subexpression depending on unknown variables
+type partial struct {
+	constant bool
+	value    float64
+	text     string
+	// precedence of the outermost operator of text
+	precedence Precedence
+}
+
+func constantPartial(value float64) partial {
+	p := partial{
+		constant:   true,
+		value:      value,
+		text:       strconv.FormatFloat(value, 'g', -1, 64),
+		precedence: atomPrecedence,
+	}
+	if value < 0 {
+		// written with a unary minus
+		p.precedence = Middle
+	}
+	return p
+}
+
+// parenthesized returns the text, in parentheses if its operator binds
+// looser than the given precedence
+func (p partial) parenthesized(precedence Precedence) string {
+	if p.precedence < precedence {
+		return "(" + p.text + ")"
+	}
+	return p.text
+}
+
+// Simplify evaluates the parts of the expression that do not depend on
+// variables missing from vars and returns the rest as an expression, e.g.
+// 2 * 3 + x is simplified to 6 + x. Only whole subexpressions are folded,
+// operations are not reordered, so x + 2 + 3 stays as it is.
+//
+// Returns the value as an expression if every variable is known.
+func (e *Evaluator) Simplify(expression string, vars map[string]float64) (string, error) {
+	return e.simplify(expression, vars, false)
+}
+
+// commutativeOperators are the infix operators whose operands can be swapped
+var commutativeOperators = map[string]bool{
+	"+":   true,
+	"*":   true,
+	"&":   true,
+	"|":   true,
+	"xor": true,
+	"==":  true,
+	"!=":  true,
+}
+
+// Canonical returns the expression simplified like Simplify without any
+// variable values, with the operands of commutative operators like + and *
+// in a stable order, so that e.g. b + a*2 and 2*a + b give the same text,
+// to detect equivalent expressions or key a cache.
+//
+// Operations are not regrouped, as floating point addition and
+// multiplication are not associative, so (a + b) + c and a + (b + c)
+// remain different.
+func (e *Evaluator) Canonical(expression string) (string, error) {
+	return e.simplify(expression, nil, true)
+}
+
+// simplify implements Simplify, sorting the operands of commutative
+// operators if canonical
+func (e *Evaluator) simplify(expression string, vars map[string]float64, canonical bool) (string, error) {
+	e.setWarnings(nil)
+	tokens, err := e.tokenize(expression)
+	if err != nil {
+		return "", err
+	}
+	polishNotation, err := e.toReversePolishNotation(tokens)
+	if err != nil {
+		return "", err
+	}
+	ev := &evaluation{ctx: context.Background()}
+	defer func() { e.setWarnings(ev.warnings) }()
+
+	var stack []partial
+	// conditionals with an unknown condition evaluate both branches, which
+	// are combined at the end of the second one
+	var pending []pendingConditional
+	for i := 0; ; i++ {
+		for len(pending) > 0 && pending[len(pending)-1].end == i {
+			c := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			if len(stack) < 2 {
+				return "", fmt.Errorf("invalid expression")
+			}
+			then, otherwise := stack[len(stack)-2], stack[len(stack)-1]
+			stack = stack[:len(stack)-2]
+			stack = append(stack, simplifyConditional(c.condition, then, otherwise))
+		}
+		if i == len(polishNotation) {
+			break
+		}
+		t := polishNotation[i]
+		switch t.Type {
+		case Number:
+			num, err := e.parseNumber(t.Value)
+			if err != nil {
+				return "", err
+			}
+			stack = append(stack, constantPartial(num))
+		case Variable:
+			value, ok := vars[t.Value]
+			if !ok {
+				value, ok = e.constant(t.Value)
+			}
+			if !ok {
+				stack = append(stack, partial{text: t.Value, precedence: atomPrecedence})
+				break
+			}
+			stack = append(stack, constantPartial(value))
+		case Operator:
+			operatorEvaluator := e.operator(t.Value)
+			if operatorEvaluator.Type() == Function {
+				if err := checkArity(t.Value, operatorEvaluator, t.args); err != nil {
+					return "", err
+				}
+			}
+			n := operandCount(t, operatorEvaluator)
+			if len(stack) < n {
+				return "", fmt.Errorf("invalid expression")
+			}
+			operands := stack[len(stack)-n:]
+			stack = stack[:len(stack)-n]
+			if canonical && commutativeOperators[t.Value] && operands[0].text > operands[1].text {
+				operands[0], operands[1] = operands[1], operands[0]
+			}
+			p, err := e.simplifyOperation(ev, t, operatorEvaluator, operands)
+			if err != nil {
+				return "", err
+			}
+			stack = append(stack, p)
+		case Question:
+			// a known condition selects a branch as evaluating does
+			if len(stack) < 1 {
+				return "", fmt.Errorf("invalid expression")
+			}
+			condition := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !condition.constant {
+				pending = append(pending, pendingConditional{condition: condition, question: i, end: -1})
+			} else if condition.value == 0 {
+				i = t.target - 1
+			}
+		case Colon:
+			// continue with the second branch of an unknown condition
+			if n := len(pending); n > 0 && polishNotation[pending[n-1].question].target == i+1 {
+				pending[n-1].end = t.target
+				break
+			}
+			i = t.target - 1
+		}
+	}
+	if len(stack) != 1 {
+		return "", fmt.Errorf("invalid expression")
+	}
+	return stack[0].text, nil
+}
+
+// simplifyOperation evaluates the operation if all its operands are known,
+// otherwise writes it as an expression
+func (e *Evaluator) simplifyOperation(ev *evaluation, t Token, operatorEvaluator OperatorEvaluator, operands []partial) (partial, error) {
+	values := make([]float64, 0, len(operands))
+	for _, operand := range operands {
+		if !operand.constant {
+			break
+		}
+		values = append(values, operand.value)
+	}
+	// a function without arguments like random() is not a constant
+	if len(values) == len(operands) && len(operands) > 0 {
+		result, err := e.apply(ev, t, operatorEvaluator, values)
+		if err != nil {
+			return partial{}, err
+		}
+		return constantPartial(result), nil
+	}
+
+	precedence := e.precedence(operatorEvaluator)
+	switch operatorEvaluator.Type() {
+	case Function:
+		args := make([]string, len(operands))
+		for i, operand := range operands {
+			args[i] = operand.text
+		}
+		return partial{
+			text:       t.Value + "(" + strings.Join(args, ", ") + ")",
+			precedence: atomPrecedence,
+		}, nil
+	case Infix:
+		left, right := operands[0], operands[1]
+		// the operand on the side the operator does not group from needs
+		// parentheses at the same precedence, e.g. x - (y - 1)
+		leftText, rightText := left.parenthesized(precedence), right.parenthesized(precedence+1)
+		if operatorEvaluator.Associativity() == RightAssoc && !e.NoPrecedence {
+			leftText, rightText = left.parenthesized(precedence+1), right.parenthesized(precedence)
+		}
+		return partial{
+			text:       leftText + " " + t.Value + " " + rightText,
+			precedence: precedence,
+		}, nil
+	case Suffix:
+		symbol := t.Value
+		if sign, ok := internalOperators[symbol]; ok {
+			symbol = sign
+		}
+		return partial{
+			text:       operands[0].parenthesized(atomPrecedence) + symbol,
+			precedence: precedence,
+		}, nil
+	}
+	symbol := t.Value
+	if sign, ok := internalOperators[symbol]; ok {
+		symbol = sign
+	} else if endsWithName(symbol) {
+		symbol += " "
+	}
+	return partial{
+		text:       symbol + operands[0].parenthesized(High),
+		precedence: precedence,
+	}, nil
+}
+
+// simplifyConditional keeps the branch chosen by a known condition,
+// otherwise writes cond ? a : b
+func simplifyConditional(condition, then, otherwise partial) partial {
+	if condition.constant {
+		if condition.value != 0 {
+			return then
+		}
+		return otherwise
+	}
+	return partial{
+		text: condition.parenthesized(Comparison) + " ? " + then.parenthesized(conditionalPrecedence) +
+			" : " + otherwise.parenthesized(conditionalPrecedence),
+		precedence: conditionalPrecedence,
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import "testing"
+
+func TestSimplifyConditional(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{"x > 1 ? x : 0", "x > 1 ? x : 0"},
+		{"1 ? x : 1 / 0", "x"},
+		{"0 ? x : y + 1", "y + 1"},
+		{"x ? 1 : y ? 2 : 3", "x ? 1 : y ? 2 : 3"},
+		{"(x ? 1 : 2) ? 3 : 4", "(x ? 1 : 2) ? 3 : 4"},
+		{"x ? 1 ? a : b : c", "x ? a : c"},
+		{"x ? 2 * 3 : 4 + 5", "x ? 6 : 9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).Simplify(tt.expression, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimplifySigns(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{"-x", "-x"},
+		{"±x", "±x"},
+		{"x%", "x%"},
+		{"2 * -x", "2 * (-x)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e := NewEvaluator(Options{})
+			got, err := e.Simplify(tt.expression, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			// the text is an expression again
+			if _, err := e.Simplify(got, nil); err != nil {
+				t.Errorf("simplified text %q: %v", got, err)
+			}
+		})
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	tests := []struct {
+		expression string
+		vars       map[string]float64
+		want       string
+	}{
+		{"2 + 3 * x", nil, "2 + 3 * x"},
+		{"(2 + 3) * x", nil, "5 * x"},
+		{"x + 2 * 3", nil, "x + 6"},
+		{"1 + 2 + x", nil, "3 + x"},
+		{"sqrt(16) * y", nil, "4 * y"},
+		{"x * (1 + 1) ^ 2", nil, "x * 4"},
+		{"x / (4 / 2)", nil, "x / 2"},
+		{"max(1, 2, x)", nil, "max(1, 2, x)"},
+		{"x - (y - 1)", nil, "x - (y - 1)"},
+		{"2 ^ x ^ 2", nil, "2 ^ x ^ 2"},
+		{"2 + 3 * x + y", map[string]float64{"x": 2}, "8 + y"},
+		{"2 + 3 * x", map[string]float64{"x": 2}, "8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).Simplify(tt.expression, tt.vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		equal bool
+	}{
+		{"a + b", "b + a", true},
+		{"a * b", "b*a", true},
+		{"b + a*2", "2*a + b", true},
+		{"x * y == y * x", "y * x == x * y", true},
+		{"(1 + 2) * a", "a * 3", true},
+		{"a - b", "b - a", false},
+		{"a / b", "b / a", false},
+		{"a ^ 2", "2 ^ a", false},
+		// not regrouped
+		{"(a + b) + c", "a + (b + c)", false},
+	}
+	e := NewEvaluator(Options{})
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			a, err := e.Canonical(tt.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := e.Canonical(tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (a == b) != tt.equal {
+				t.Errorf("got %q and %q, want equal %v", a, b, tt.equal)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// TokenizeStream lexes the expression read from r incrementally, sending
+// each token as soon as the rune after it is read, so a long expression
+// is lexed while it is being read, with or without whitespace.
+//
+// The token channel is closed when the input ends or on the first error,
+// which is then sent on the error channel. The caller must drain the token
+// channel, use TokenizeStreamContext to stop reading early. Only lexical
+// errors are reported, the order of the tokens is not validated as it is
+// for evaluation.
+func (e *Evaluator) TokenizeStream(r io.Reader) (<-chan Token, <-chan error) {
+	return e.TokenizeStreamContext(context.Background(), r)
+}
+
+// TokenizeStreamContext is TokenizeStream that stops reading when ctx is
+// done, sending the error of the context. The caller can stop reading
+// the token channel after canceling ctx, the lexer no longer blocks on it,
+// but returns only once the pending read of r returns.
+func (e *Evaluator) TokenizeStreamContext(ctx context.Context, r io.Reader) (<-chan Token, <-chan error) {
+	tokens := make(chan Token)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(tokens)
+		reader := bufio.NewReader(contextReader{ctx: ctx, r: r})
+		err := e.lexReader(reader, func(t Token) error {
+			select {
+			case tokens <- t:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return tokens, errs
+}
+
+// contextReader stops reading r once ctx is done, so that input without
+// tokens, like endless whitespace, is not read on after canceling
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,133 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// collect reads all tokens and the error of a stream
+func collect(tokens <-chan Token, errs <-chan error) ([]Token, error) {
+	var got []Token
+	for t := range tokens {
+		got = append(got, t)
+	}
+	return got, <-errs
+}
+
+func TestTokenizeStream(t *testing.T) {
+	tests := []string{
+		"1+2*x",
+		"sqrt(4) - 0x1f",
+		"2.5e-3 * (1 +   2)\n/ 4",
+		"max(1, 2) ? 3 : 4",
+		"1e3",
+		"",
+	}
+	e := NewEvaluator(Options{})
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			want, err := e.lex(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := collect(e.TokenizeStream(strings.NewReader(input)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestTokenizeStreamError(t *testing.T) {
+	got, err := collect(NewEvaluator(Options{}).TokenizeStream(strings.NewReader("1 + 2 $ 3")))
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Pos != 6 {
+		t.Fatalf("got error %v, want a syntax error at 6", err)
+	}
+	values := make([]string, len(got))
+	for i, token := range got {
+		values[i] = token.Value
+	}
+	if !slices.Equal(values, []string{"1", "+", "2"}) {
+		t.Errorf("got tokens %v before the error, want 1 + 2", values)
+	}
+}
+
+// TestTokenizeStreamIncremental checks that tokens are sent before the
+// input ends, without whitespace to separate them
+func TestTokenizeStreamIncremental(t *testing.T) {
+	r, w := io.Pipe()
+	tokens, errs := NewEvaluator(Options{}).TokenizeStream(r)
+	go w.Write([]byte("12+"))
+
+	select {
+	case token := <-tokens:
+		if token.Value != "12" {
+			t.Errorf("got %v, want 12", token)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no token before the input ended")
+	}
+
+	go func() {
+		w.Write([]byte("3"))
+		w.Close()
+	}()
+	got, err := collect(tokens, errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Value != "+" || got[1].Value != "3" {
+		t.Errorf("got %v, want + 3", got)
+	}
+}
+
+func TestTokenizeStreamContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	input := strings.NewReader(strings.Repeat("1+", 1000) + "1")
+	tokens, errs := NewEvaluator(Options{}).TokenizeStreamContext(ctx, input)
+	<-tokens
+	cancel()
+
+	// the tokens are not drained, the lexer must stop on its own
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the lexer did not stop after canceling")
+	}
+}
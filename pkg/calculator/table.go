@@ -0,0 +1,72 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"cmp"
+	"slices"
+)
+
+// OperatorInfo describes how an operator or function is parsed
+type OperatorInfo struct {
+	Symbol        string
+	Precedence    Precedence
+	Type          Type
+	Associativity Assoc
+}
+
+// OperatorLister is implemented by factories able to list the operators
+// they create, like the one of NewOperatorEvaluatorFactory
+type OperatorLister interface {
+	// Operators returns the operators and functions of the factory
+	Operators() []string
+}
+
+// PrecedenceTable returns the operators of the factory from the highest to
+// the lowest precedence, then by symbol. Returns nil if the factory does
+// not implement OperatorLister.
+func PrecedenceTable(factory OperatorEvaluatorFactory) []OperatorInfo {
+	lister, ok := factory.(OperatorLister)
+	if !ok {
+		return nil
+	}
+	var table []OperatorInfo
+	for _, symbol := range lister.Operators() {
+		operatorEvaluator := factory.Create(symbol)
+		table = append(table, OperatorInfo{
+			Symbol:        symbol,
+			Precedence:    operatorEvaluator.Precedence(),
+			Type:          operatorEvaluator.Type(),
+			Associativity: operatorEvaluator.Associativity(),
+		})
+	}
+	slices.SortFunc(table, func(a, b OperatorInfo) int {
+		return cmp.Or(
+			cmp.Compare(b.Precedence, a.Precedence),
+			cmp.Compare(a.Symbol, b.Symbol),
+		)
+	})
+	return table
+}
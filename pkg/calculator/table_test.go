@@ -0,0 +1,75 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPrecedenceTable(t *testing.T) {
+	factory := NewOperatorEvaluatorFactory()
+	table := PrecedenceTable(factory)
+	if len(table) != len(factory.(OperatorLister).Operators()) {
+		t.Fatalf("got %d operators, want %d", len(table), len(factory.(OperatorLister).Operators()))
+	}
+	for i := 1; i < len(table); i++ {
+		a, b := table[i-1], table[i]
+		if a.Precedence < b.Precedence || a.Precedence == b.Precedence && a.Symbol > b.Symbol {
+			t.Errorf("%v is listed before %v", a, b)
+		}
+	}
+
+	want := []OperatorInfo{
+		{"!", High, Suffix, LeftAssoc},
+		{"sqrt", High, Function, LeftAssoc},
+		{"^", High, Infix, RightAssoc},
+		{"**", High, Infix, RightAssoc},
+		{"*", Middle, Infix, LeftAssoc},
+		{"neg", Middle, Prefix, LeftAssoc},
+		{"+", Normal, Infix, LeftAssoc},
+		{"&", Low, Infix, LeftAssoc},
+		{"==", Comparison, Infix, LeftAssoc},
+	}
+	for _, info := range want {
+		i := slices.IndexFunc(table, func(got OperatorInfo) bool { return got.Symbol == info.Symbol })
+		if i < 0 {
+			t.Errorf("%s is missing", info.Symbol)
+		} else if table[i] != info {
+			t.Errorf("got %v, want %v", table[i], info)
+		}
+	}
+}
+
+// unlistedFactory is a factory that cannot list its operators
+type unlistedFactory struct {
+	OperatorEvaluatorFactory
+}
+
+func TestPrecedenceTableUnlisted(t *testing.T) {
+	if table := PrecedenceTable(unlistedFactory{NewOperatorEvaluatorFactory()}); table != nil {
+		t.Errorf("got %v, want nil", table)
+	}
+}
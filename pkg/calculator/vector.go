@@ -0,0 +1,254 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// vectorValue is a value of the vector mode, a number unless it has
+// elements
+type vectorValue struct {
+	number   float64
+	elements []float64
+}
+
+func (v vectorValue) isVector() bool {
+	return v.elements != nil
+}
+
+// EvaluateVector evaluates an expression over vectors, written as comma
+// separated numbers in square brackets like [1, 2, 3], returning the
+// elements of the resulting vector, or a single one for a number, e.g.
+//
+//	[1, 2, 3] + [4, 5, 6] is [5, 7, 9]
+//	dot([1, 2, 3], [4, 5, 6]) is [32]
+//
+// + and - take two vectors of the same length and apply element-wise,
+// * and / scale a vector by a number, and - negates one. dot returns the
+// dot product of two vectors of the same length. Anything else takes
+// numbers only, as in EvaluateExpression. Square brackets do not group
+// unless they call a function like sqrt[4], parentheses do. Vector
+// operations are not reported to the audit hook.
+func (e *Evaluator) EvaluateVector(expression string) ([]float64, error) {
+	e.setWarnings(nil)
+	tokens, err := e.tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("no tokens found")
+	}
+	polishNotation, _, err := e.toReversePolishNotationGroups(tokens, true)
+	if err != nil {
+		return nil, err
+	}
+	ev := &evaluation{ctx: context.Background()}
+	defer func() { e.setWarnings(ev.warnings) }()
+	result, err := e.runVector(ev, polishNotation)
+	if err != nil {
+		return nil, err
+	}
+	if !result.isVector() {
+		result.elements = []float64{result.number}
+	}
+	for i, element := range result.elements {
+		if result.elements[i], err = e.checkFinal(element); err != nil {
+			return nil, err
+		}
+	}
+	return result.elements, nil
+}
+
+func (e *Evaluator) runVector(ev *evaluation, polishNotation []Token) (vectorValue, error) {
+	var stack []vectorValue
+	for i := 0; i < len(polishNotation); i++ {
+		t := polishNotation[i]
+		switch t.Type {
+		case Number:
+			num, err := e.parseNumber(t.Value)
+			if err != nil {
+				return vectorValue{}, err
+			}
+			stack = append(stack, vectorValue{number: num})
+		case Variable:
+			value, ok := e.constant(t.Value)
+			if !ok {
+				return vectorValue{}, fmt.Errorf("undefined variable: %s", t.Value)
+			}
+			stack = append(stack, vectorValue{number: value})
+		case Operator:
+			if err := ev.ctx.Err(); err != nil {
+				return vectorValue{}, err
+			}
+			if t.Value == "[" {
+				if len(stack) < t.args {
+					return vectorValue{}, fmt.Errorf("invalid expression")
+				}
+				vector, err := newVector(stack[len(stack)-t.args:])
+				if err != nil {
+					return vectorValue{}, err
+				}
+				stack = append(stack[:len(stack)-t.args], vector)
+				break
+			}
+			if err := e.checkEnabled(ev, t.Value); err != nil {
+				return vectorValue{}, err
+			}
+			operatorEvaluator := e.operator(t.Value)
+			if operatorEvaluator.Type() == Function {
+				if err := checkArity(t.Value, operatorEvaluator, t.args); err != nil {
+					return vectorValue{}, err
+				}
+			}
+			n := operandCount(t, operatorEvaluator)
+			if len(stack) < n {
+				return vectorValue{}, fmt.Errorf("invalid expression")
+			}
+			operands := slices.Clone(stack[len(stack)-n:])
+			stack = stack[:len(stack)-n]
+			if slices.ContainsFunc(operands, vectorValue.isVector) {
+				result, err := applyVector(t.Value, operands)
+				if err != nil {
+					return vectorValue{}, err
+				}
+				stack = append(stack, result)
+				break
+			}
+			numbers := make([]float64, n)
+			for j, operand := range operands {
+				numbers[j] = operand.number
+			}
+			result, err := e.apply(ev, t, operatorEvaluator, numbers)
+			if err != nil {
+				return vectorValue{}, err
+			}
+			e.record(t.Value, numbers, result)
+			if err := e.checkResult(ev, t.Value, numbers, result); err != nil {
+				return vectorValue{}, err
+			}
+			stack = append(stack, vectorValue{number: result})
+		case Question:
+			if len(stack) < 1 {
+				return vectorValue{}, fmt.Errorf("invalid expression")
+			}
+			condition := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if condition.isVector() {
+				return vectorValue{}, errors.New("the condition of ? must be a number, not a vector")
+			}
+			if condition.number == 0 {
+				i = t.target - 1
+			}
+		case Colon:
+			i = t.target - 1
+		}
+	}
+	if len(stack) != 1 {
+		return vectorValue{}, fmt.Errorf("invalid expression")
+	}
+	return stack[0], nil
+}
+
+// newVector returns the vector of the elements, which must be numbers
+func newVector(elements []vectorValue) (vectorValue, error) {
+	vector := vectorValue{elements: make([]float64, len(elements))}
+	for i, element := range elements {
+		if element.isVector() {
+			return vectorValue{}, errors.New("vectors cannot be nested")
+		}
+		vector.elements[i] = element.number
+	}
+	return vector, nil
+}
+
+// applyVector applies an operator with at least one vector operand
+func applyVector(op string, operands []vectorValue) (vectorValue, error) {
+	switch op {
+	case "neg":
+		return mapVector(operands[0], func(x float64) float64 { return -x }), nil
+	case "+", "-", "dot":
+		left, right := operands[0], operands[1]
+		if !left.isVector() || !right.isVector() {
+			return vectorValue{}, fmt.Errorf("%s requires two vectors, not a vector and a number", op)
+		}
+		if len(left.elements) != len(right.elements) {
+			return vectorValue{}, fmt.Errorf("%s requires vectors of the same length, got %d and %d",
+				op, len(left.elements), len(right.elements))
+		}
+		switch op {
+		case "+":
+			return zipVectors(left, right, func(a, b float64) float64 { return a + b }), nil
+		case "-":
+			return zipVectors(left, right, func(a, b float64) float64 { return a - b }), nil
+		}
+		var product float64
+		for i := range left.elements {
+			product += left.elements[i] * right.elements[i]
+		}
+		return vectorValue{number: product}, nil
+	case "*":
+		vector, factor := operands[0], operands[1]
+		if !vector.isVector() {
+			vector, factor = factor, vector
+		}
+		if factor.isVector() {
+			return vectorValue{}, errors.New("* of two vectors is not defined, use dot")
+		}
+		return mapVector(vector, func(x float64) float64 { return x * factor.number }), nil
+	case "/":
+		vector, divisor := operands[0], operands[1]
+		if !vector.isVector() || divisor.isVector() {
+			return vectorValue{}, errors.New("/ divides a vector by a number only")
+		}
+		if divisor.number == 0 {
+			return vectorValue{}, errors.New("division by zero")
+		}
+		return mapVector(vector, func(x float64) float64 { return x / divisor.number }), nil
+	}
+	return vectorValue{}, fmt.Errorf("%s does not take vectors", op)
+}
+
+// mapVector applies f to each element of the vector
+func mapVector(vector vectorValue, f func(float64) float64) vectorValue {
+	result := vectorValue{elements: make([]float64, len(vector.elements))}
+	for i, x := range vector.elements {
+		result.elements[i] = f(x)
+	}
+	return result
+}
+
+// zipVectors applies f to the elements of two vectors of the same length
+// pairwise
+func zipVectors(left, right vectorValue, f func(float64, float64) float64) vectorValue {
+	result := vectorValue{elements: make([]float64, len(left.elements))}
+	for i := range left.elements {
+		result.elements[i] = f(left.elements[i], right.elements[i])
+	}
+	return result
+}
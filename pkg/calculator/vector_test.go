@@ -0,0 +1,98 @@
+/*
+ * MIT License
+ *
+ * Copyright (c) 2025 RollW
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package calculator
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEvaluateVector(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       []float64
+	}{
+		{"[1, 2, 3] + [4, 5, 6]", []float64{5, 7, 9}},
+		{"[1, 2, 3] - [4, 5, 6]", []float64{-3, -3, -3}},
+		{"dot([1, 2, 3], [4, 5, 6])", []float64{32}},
+		{"dot([1, 0], [0, 1])", []float64{0}},
+		{"2 * [1, 2]", []float64{2, 4}},
+		{"[1, 2] * 2 - [1, 1]", []float64{1, 3}},
+		{"2[1, 2]", []float64{2, 4}},
+		{"[1, 2] / 2", []float64{0.5, 1}},
+		{"-[1, 2]", []float64{-1, -2}},
+		{"[1 + 1, sqrt(9), pi - pi]", []float64{2, 3, 0}},
+		{"[7]", []float64{7}},
+		{"(1 + 2) * 3", []float64{9}},
+		{"1 > 0 ? [1, 2] : [3, 4]", []float64{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := NewEvaluator(Options{}).EvaluateVector(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateVectorErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		err        string
+	}{
+		{"[1, 2] + [1, 2, 3]", "same length, got 2 and 3"},
+		{"[1, 2, 3] - [1, 2]", "same length, got 3 and 2"},
+		{"dot([1, 2], [1])", "same length, got 2 and 1"},
+		{"[1, 2] + 1", "two vectors"},
+		{"dot([1, 2], 3)", "two vectors"},
+		{"[1, 2] * [3, 4]", "use dot"},
+		{"2 / [1, 2]", "by a number only"},
+		{"[1, 2] / 0", "division by zero"},
+		{"sqrt([4])", "does not take vectors"},
+		{"[[1, 2], 3]", "cannot be nested"},
+		{"[1, 2] ? 1 : 2", "must be a number"},
+		{"[1, ]", "empty element 2 of vector"},
+		{"[1, 2)", "mismatched"},
+		{"(1, 2)", "unexpected comma"},
+		{"dot(1, 2)", "requires two vectors"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := NewEvaluator(Options{}).EvaluateVector(tt.expression)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDotOutsideVectorMode(t *testing.T) {
+	runExpressionErrors(t, Options{}, "dot(1, 2)", "[1, 2] + [3, 4]")
+}